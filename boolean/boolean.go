@@ -14,3 +14,16 @@
 
 // Package boolean provides OpenSCAD boolean types.
 package boolean
+
+import (
+	"reflect"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// init registers the types of this package to be decoded by scad.Decode.
+func init() {
+	scad.RegisterType("difference", reflect.TypeOf(Difference{}))
+	scad.RegisterType("intersection", reflect.TypeOf(Intersection{}))
+	scad.RegisterType("union", reflect.TypeOf(Union{}))
+}
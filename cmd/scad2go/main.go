@@ -0,0 +1,57 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command scad2go generates Go types from the module definitions of OpenSCAD files.
+//
+// Usage:
+//
+//	scad2go [-package name] [-o output.go] file.scad...
+//
+// The generated source is written to standard output unless -o is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go.incompletion.ist/go-scad/scad2go"
+)
+
+func main() {
+	pkg := flag.String("package", "main", "package name of the generated source")
+	output := flag.String("o", "", "file to write the generated source to (default stdout)")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: scad2go [-package name] [-o output.go] file.scad...")
+		os.Exit(2)
+	}
+
+	src, err := scad2go.GenerateFiles(*pkg, flag.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failure:", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(src)
+		return
+	}
+
+	if err := os.WriteFile(*output, src, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, "failure:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command scaddiff reports the structural differences between two OpenSCAD files, or two
+// directories of generated OpenSCAD files.
+//
+// Usage:
+//
+//	scaddiff old new
+//
+// Each added, removed, or changed node is printed on its own line, along with modules whose
+// definitions diverged. The exit status is 0 if there are no differences, 1 if there are,
+// and 2 if there was a failure.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go.incompletion.ist/go-scad/parser"
+	"go.incompletion.ist/go-scad/scaddiff"
+)
+
+func main() {
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: scaddiff old new")
+		os.Exit(2)
+	}
+
+	diffStrings, err := diff(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failure:", err)
+		os.Exit(2)
+	}
+
+	for _, diffString := range diffStrings {
+		fmt.Println(diffString)
+	}
+
+	if len(diffStrings) > 0 {
+		os.Exit(1)
+	}
+}
+
+// diff returns the descriptions of the differences between the old and new files or
+// directories.
+func diff(oldPath, newPath string) ([]string, error) {
+	oldInfo, err := os.Stat(oldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	newInfo, err := os.Stat(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffStrings []string
+
+	switch {
+	case oldInfo.IsDir() && newInfo.IsDir():
+		diffs, err := scaddiff.Dirs(oldPath, newPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, diff := range diffs {
+			diffStrings = append(diffStrings, diff.Strings()...)
+		}
+	case !oldInfo.IsDir() && !newInfo.IsDir():
+		oldFile, err := parser.ParseFile(oldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		newFile, err := parser.ParseFile(newPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range scaddiff.Files(oldFile, newFile) {
+			diffStrings = append(diffStrings, change.String())
+		}
+	default:
+		return nil, fmt.Errorf("can't compare a file with a directory")
+	}
+
+	return diffStrings, nil
+}
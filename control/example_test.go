@@ -0,0 +1,111 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package control provides OpenSCAD control flow types, such as for loops and conditionals.
+package control_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/control"
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/transformation"
+	"go.incompletion.ist/go-scad/value"
+)
+
+func ExampleFor() {
+	i := value.Var("i")
+
+	row := scad.Apply(
+		primitive3d.Cube{Size: value.NewFloat(5)},
+		transformation.Translate{
+			V: value.NewFloatXYZExpr(value.Vector(i.Mul(value.Num(10)), value.Num(0), value.Num(0))),
+		},
+		control.For{
+			Variable: "i",
+			Values:   value.Range(value.Num(0), value.Num(1), value.Num(9)),
+		},
+	)
+
+	content, _ := scad.FunctionContent(row)
+	fmt.Println(content)
+	// Output: for(i=[0:1:9]) {
+	//   translate(v=[i * 10, 0, 0]) {
+	//     cube(size=5);
+	//   }
+	// }
+}
+
+func ExampleIntersectionFor() {
+	angle := value.Var("angle")
+
+	star := scad.Apply(
+		primitive3d.Cube{
+			SizeXYZ: value.NewFloatXYZ(20, 5, 5),
+			Center:  value.NewBool(true),
+		},
+		transformation.Rotate{
+			A: value.NewFloatExpr(angle),
+		},
+		control.IntersectionFor{
+			Variable: "angle",
+			Values:   value.Vector(value.Num(0), value.Num(30), value.Num(60)),
+		},
+	)
+
+	content, _ := scad.FunctionContent(star)
+	fmt.Println(content)
+	// Output: intersection_for(angle=[0, 30, 60]) {
+	//   rotate(a=angle) {
+	//     cube(center=true, size=[20, 5, 5]);
+	//   }
+	// }
+}
+
+func ExampleIf() {
+	shape := control.If{
+		Condition: value.Var("round"),
+		Children:  []interface{}{primitive3d.Sphere{R: value.NewFloat(5)}},
+		Else:      []interface{}{primitive3d.Cube{Size: value.NewFloat(10)}},
+	}
+
+	content, _ := scad.FunctionContent(shape)
+	fmt.Println(content)
+	// Output: if(round) {
+	//   sphere(r=5);
+	// } else {
+	//   cube(size=10);
+	// }
+}
+
+func ExampleLet() {
+	size := value.Var("size")
+
+	block := scad.Apply(
+		primitive3d.Cube{Size: value.NewFloatExpr(size)},
+		control.Let{
+			Variables: scad.Variables{
+				{Name: "base", Value: 10},
+				{Name: "size", Value: value.Var("base").Mul(value.Num(2))},
+			},
+		},
+	)
+
+	content, _ := scad.FunctionContent(block)
+	fmt.Println(content)
+	// Output: let(base=10, size=base * 2) {
+	//   cube(size=size);
+	// }
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/value"
+)
+
+// loopBlock returns the Block for a loop of the given name.
+func loopBlock(name string, variable string, values value.Expr, children []interface{}) (scad.Block, error) {
+	if variable == "" || !values.IsSet() {
+		return scad.Block{}, fmt.Errorf("control: %s requires a Variable and Values", name)
+	}
+
+	fn := scad.Function{Name: name}
+	fn.SetParameter(variable, values.String())
+
+	return scad.Block{
+		Function: fn,
+		Children: children,
+	}, nil
+}
+
+// For is a for loop, which instantiates its Children once for each of its Values, with
+// Variable set to the value.
+type For struct {
+	// Variable is the name of the loop variable, such as "i".
+	Variable string `scad:"-"`
+
+	// Values is the range or list of values to loop over, such as value.Range or
+	// value.Vector.
+	Values value.Expr `scad:"-"`
+
+	Children []interface{} `scad:"-"`
+}
+
+// Wrap wraps a child with this For.
+func (f For) Wrap(child interface{}) scad.Wrapper {
+	f.Children = append([]interface{}{child}, f.Children...)
+
+	return f
+}
+
+// EncodeSCAD implements custom encoding for scad.Encode.
+func (f For) EncodeSCAD() (interface{}, error) {
+	return loopBlock("for", f.Variable, f.Values, f.Children)
+}
+
+// IntersectionFor is an intersection_for loop, which intersects its Children instantiated
+// once for each of its Values, with Variable set to the value.
+type IntersectionFor struct {
+	// Variable is the name of the loop variable, such as "i".
+	Variable string `scad:"-"`
+
+	// Values is the range or list of values to loop over, such as value.Range or
+	// value.Vector.
+	Values value.Expr `scad:"-"`
+
+	Children []interface{} `scad:"-"`
+}
+
+// Wrap wraps a child with this IntersectionFor.
+func (f IntersectionFor) Wrap(child interface{}) scad.Wrapper {
+	f.Children = append([]interface{}{child}, f.Children...)
+
+	return f
+}
+
+// EncodeSCAD implements custom encoding for scad.Encode.
+func (f IntersectionFor) EncodeSCAD() (interface{}, error) {
+	return loopBlock("intersection_for", f.Variable, f.Values, f.Children)
+}
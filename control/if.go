@@ -0,0 +1,55 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/value"
+)
+
+// If is a conditional, which instantiates its Children if Condition is true, or its Else
+// children otherwise.
+type If struct {
+	Condition value.Expr `scad:"-"`
+
+	Children []interface{} `scad:"-"`
+
+	Else []interface{} `scad:"-"`
+}
+
+// Wrap wraps a child with this If.
+func (i If) Wrap(child interface{}) scad.Wrapper {
+	i.Children = append([]interface{}{child}, i.Children...)
+
+	return i
+}
+
+// EncodeSCAD implements custom encoding for scad.Encode.
+func (i If) EncodeSCAD() (interface{}, error) {
+	if !i.Condition.IsSet() {
+		return nil, fmt.Errorf("control: if requires a Condition")
+	}
+
+	return scad.Block{
+		Function: scad.Function{
+			Name:      "if",
+			Arguments: []string{i.Condition.String()},
+		},
+		Children: i.Children,
+		Else:     i.Else,
+	}, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import "go.incompletion.ist/go-scad/scad"
+
+// Let assigns its Variables, in order, for the scope of its Children only.
+type Let struct {
+	Variables scad.Variables `scad:"-"`
+
+	Children []interface{} `scad:"-"`
+}
+
+// Wrap wraps a child with this Let.
+func (l Let) Wrap(child interface{}) scad.Wrapper {
+	l.Children = append([]interface{}{child}, l.Children...)
+
+	return l
+}
+
+// EncodeSCAD implements custom encoding for scad.Encode. The Variables are encoded along
+// with the Children, with the same Encoder.
+func (l Let) EncodeSCAD() (interface{}, error) {
+	return scad.Block{
+		Function:  scad.Function{Name: "let"},
+		Arguments: l.Variables,
+		Children:  l.Children,
+	}, nil
+}
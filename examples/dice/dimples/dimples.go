@@ -17,7 +17,7 @@ package dimples
 import (
 	"fmt"
 
-	"go.incompletion.ist/go-scad/boolean"
+	"go.incompletion.ist/go-scad/control"
 	"go.incompletion.ist/go-scad/scad"
 	"go.incompletion.ist/go-scad/transformation"
 	"go.incompletion.ist/go-scad/value"
@@ -76,29 +76,47 @@ type Dimples struct {
 	Count int
 }
 
+// ValidateSCAD implements validation for scad.Encode.
+func (d Dimples) ValidateSCAD() error {
+	if d.Count < 0 || d.Count > 6 {
+		return fmt.Errorf("dimples: Dimples Count is %d, must be between 0 and 6", d.Count)
+	}
+
+	return nil
+}
+
 // EncodeSCAD implements custom encoding for scad.Encode.
 func (d Dimples) EncodeSCAD() (interface{}, error) {
-	if d.Count < 0 || d.Count > 6 {
-		return nil, fmt.Errorf("dimples: Dimples Count is %d, must be between 0 and 6", d.Count)
+	// scad.Encode validates before encoding, but EncodeSCAD may be called directly
+	if err := d.ValidateSCAD(); err != nil {
+		return nil, err
 	}
 
 	dimpleLayout := dimplePositions[d.Count]
-	dimples := make([]interface{}, d.Count)
+	positions := make([]value.Expr, len(dimpleLayout))
 
 	for i, dimplePosition := range dimpleLayout {
-		dimples[i] = scad.Apply(
-			d.Dimple,
-			transformation.Translate{
-				V: value.NewFloatXYZ(
-					float64(dimplePosition[0])*(d.Width/4),
-					float64(dimplePosition[1])*(d.Width/4),
-					0,
-				),
-			},
+		positions[i] = value.Vector(
+			value.Num(float64(dimplePosition[0])),
+			value.Num(float64(dimplePosition[1])),
 		)
 	}
 
-	return boolean.Union{
-		Children: dimples,
-	}, nil
+	position := value.Var("position")
+	spacing := value.Num(d.Width / 4)
+
+	return scad.Apply(
+		d.Dimple,
+		transformation.Translate{
+			V: value.NewFloatXYZExpr(value.Vector(
+				position.Index(value.Num(0)).Mul(spacing),
+				position.Index(value.Num(1)).Mul(spacing),
+				value.Num(0),
+			)),
+		},
+		control.For{
+			Variable: "position",
+			Values:   value.Vector(positions...),
+		},
+	), nil
 }
@@ -14,3 +14,15 @@
 
 // Package extrusion provides OpenSCAD extrusion types.
 package extrusion
+
+import (
+	"reflect"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// init registers the types of this package to be decoded by scad.Decode.
+func init() {
+	scad.RegisterType("linear_extrude", reflect.TypeOf(LinearExtrude{}))
+	scad.RegisterType("rotate_extrude", reflect.TypeOf(RotateExtrude{}))
+}
@@ -23,14 +23,14 @@ import (
 type LinearExtrude struct {
 	linearExtrude scad.AutoFunctionName `scad:"linear_extrude"` //nolint:golint,structcheck,unused
 
-	Height value.Float `scad:"height"`
+	Height value.Float `scad:"height,min=0"`
 	Twist  value.Float `scad:"twist"`
 	Center value.Bool  `scad:"center"`
-	Slices value.Int   `scad:"slices"`
+	Slices value.Int   `scad:"slices,min=1"`
 
 	// Only one of Scale or ScaleXY should be set.
-	Scale   value.Float   `scad:"scale"`
-	ScaleXY value.FloatXY `scad:"scale"`
+	Scale   value.Float   `scad:"scale,oneof=scale,min=0"`
+	ScaleXY value.FloatXY `scad:"scale,oneof=scale,min=0"`
 
 	FN value.Int `scad:"$fn"`
 
@@ -0,0 +1,143 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package syntax provides the lexing of OpenSCAD source shared by the packages that read or
+// rewrite it.
+package syntax
+
+import (
+	"strings"
+)
+
+// IsDigit returns a boolean indicating if c is a decimal digit.
+func IsDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// IsIdentifierStart returns a boolean indicating if c may start an identifier. Special
+// variables start with "$".
+func IsIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// IsIdentifierPart returns a boolean indicating if c may be part of an identifier.
+func IsIdentifierPart(c byte) bool {
+	return IsIdentifierStart(c) || IsDigit(c)
+}
+
+// NumberLength returns the length of the number literal at the start of s, including any
+// exponent, which may be signed.
+func NumberLength(s string) int {
+	n := 0
+	for n < len(s) && (IsDigit(s[n]) || s[n] == '.') {
+		n++
+	}
+
+	if n < len(s) && (s[n] == 'e' || s[n] == 'E') {
+		exp := n + 1
+		if exp < len(s) && (s[exp] == '+' || s[exp] == '-') {
+			exp++
+		}
+		if exp < len(s) && IsDigit(s[exp]) {
+			n = exp
+			for n < len(s) && IsDigit(s[n]) {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// Identifiers returns the identifiers that source refers to, in order, which may repeat.
+// Those within strings, following a "." as a member, or followed by a single "=" as the
+// name of an argument or binding, aren't references and are left out.
+func Identifiers(source string) []string {
+	var identifiers []string
+
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+
+		switch {
+		case c == '"':
+			// skip to the end of the string
+			for i++; i < len(source) && source[i] != '"'; i++ {
+				if source[i] == '\\' {
+					i++
+				}
+			}
+		case IsDigit(c) || (c == '.' && i+1 < len(source) && IsDigit(source[i+1])):
+			i += NumberLength(source[i:]) - 1
+		case IsIdentifierStart(c):
+			end := i + 1
+			for end < len(source) && IsIdentifierPart(source[end]) {
+				end++
+			}
+
+			next := strings.TrimLeft(source[end:], " \t\n")
+			isMember := strings.HasSuffix(strings.TrimRight(source[:i], " \t\n"), ".")
+			isName := strings.HasPrefix(next, "=") && !strings.HasPrefix(next, "==")
+
+			if !isMember && !isName {
+				identifiers = append(identifiers, source[i:end])
+			}
+			i = end - 1
+		}
+	}
+
+	return identifiers
+}
+
+// SplitElements splits the inside of a vector on commas that aren't nested within other
+// vectors, calls, or strings, trimming the space around each element. A false boolean is
+// returned for ranges, such as "0:2", and unbalanced brackets.
+func SplitElements(inner string) ([]string, bool) {
+	if strings.TrimSpace(inner) == "" {
+		return nil, true
+	}
+
+	var elements []string
+	var depth, start int
+	var inString, escaped bool
+
+	for i, r := range inner {
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case inString:
+		case r == '[' || r == '(':
+			depth++
+		case r == ']' || r == ')':
+			depth--
+			if depth < 0 {
+				return nil, false
+			}
+		case r == ':' && depth == 0:
+			return nil, false
+		case r == ',' && depth == 0:
+			elements = append(elements, strings.TrimSpace(inner[start:i]))
+			start = i + 1
+		}
+	}
+
+	if depth != 0 || inString {
+		return nil, false
+	}
+
+	return append(elements, strings.TrimSpace(inner[start:])), true
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNumberLength(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{input: "10", want: 2},
+		{input: "1.5, 2", want: 3},
+		{input: ".5]", want: 2},
+		{input: "2.5e-3 * x", want: 6},
+		{input: "1e", want: 1},
+		{input: "x", want: 0},
+	}
+
+	for _, test := range tests {
+		if got := NumberLength(test.input); got != test.want {
+			t.Errorf("NumberLength(%q) got %d, want %d", test.input, got, test.want)
+		}
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "10", want: nil},
+		{input: "[i * 2.5e3, $fn, v.x]", want: []string{"i", "$fn", "v"}},
+		{input: `str("a", b, "c\" d")`, want: []string{"str", "b"}},
+		{input: "f(x=1, y == z)", want: []string{"f", "y", "z"}},
+		{input: "r1 + .5", want: []string{"r1"}},
+	}
+
+	for _, test := range tests {
+		got := Identifiers(test.input)
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Identifiers(%q) got\n%#v, want\n%#v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestSplitElements(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     []string
+		wantBool bool
+	}{
+		{
+			name:     "empty",
+			input:    " ",
+			wantBool: true,
+		},
+		{
+			name:     "nested",
+			input:    `[0, 0], f(1, 2), "a, b", [x]`,
+			want:     []string{"[0, 0]", "f(1, 2)", `"a, b"`, "[x]"},
+			wantBool: true,
+		},
+		{
+			name:  "range",
+			input: "0:2",
+		},
+		{
+			name:  "unbalanced",
+			input: "[0, 0]]",
+		},
+	}
+
+	for _, test := range tests {
+		got, gotBool := SplitElements(test.input)
+
+		if gotBool != test.wantBool || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q SplitElements() got\n%#v, %v, want\n%#v, %v", test.name, got, gotBool, test.want, test.wantBool)
+		}
+	}
+}
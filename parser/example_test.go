@@ -0,0 +1,44 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/parser"
+	"go.incompletion.ist/go-scad/scad"
+)
+
+func ExampleParse() {
+	src := `
+wall = 2;
+difference() {
+  cube(20);
+  translate([wall, wall, wall]) cube(20 - 2*wall); // hollow
+}
+`
+
+	file, _ := parser.Parse("box.scad", []byte(src))
+
+	content, _ := scad.FunctionContent(file.Root)
+	fmt.Println(content)
+	// Output: wall = 2;
+	// difference() {
+	//   cube(20);
+	//   translate([wall, wall, wall]) {
+	//     cube(20 - 2*wall);
+	//   }
+	// }
+}
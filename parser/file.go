@@ -0,0 +1,180 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// File is a parsed OpenSCAD source file.
+type File struct {
+	// Uses is the paths of the file's "use" statements, in order.
+	Uses []string
+
+	// Includes is the paths of the file's "include" statements, in order.
+	Includes []string
+
+	// Modules is the file's module definitions, in order.
+	Modules []Module
+
+	// Functions is the file's function definitions, in order.
+	Functions []FunctionDefinition
+
+	// Root is a group Function (empty Name) holding the file's top level assignments
+	// and statements.
+	Root scad.Function
+}
+
+// Module is a parsed module definition.
+type Module struct {
+	// Name is the module's name.
+	Name string
+
+	// Parameters is the module's declared parameters, in order. A parameter without a
+	// default value has an empty Value.
+	Parameters []scad.Assignment
+
+	// Modules is the module definitions in the module's body, in order, which are only
+	// visible within the module.
+	Modules []Module
+
+	// Functions is the function definitions in the module's body, in order, which are only
+	// visible within the module.
+	Functions []FunctionDefinition
+
+	// Body is a group Function (empty Name) holding the module's assignments and statements.
+	Body scad.Function
+}
+
+// FunctionDefinition is a parsed function definition.
+type FunctionDefinition struct {
+	// Name is the function's name.
+	Name string
+
+	// Parameters is the function's declared parameters, in order. A parameter without a
+	// default value has an empty Value.
+	Parameters []scad.Assignment
+
+	// Expression is the function's expression in string form.
+	Expression string
+}
+
+// declarationString returns parameters as they are declared for a module or function.
+func declarationString(params []scad.Assignment) string {
+	paramStrings := make([]string, len(params))
+
+	for i, param := range params {
+		paramStrings[i] = param.Name
+		if param.Value != "" {
+			paramStrings[i] = fmt.Sprintf("%s=%s", param.Name, param.Value)
+		}
+	}
+
+	return strings.Join(paramStrings, ", ")
+}
+
+// groupContentStrings returns the content lines of a group Function, without a
+// trailing empty line.
+func groupContentStrings(group scad.Function) ([]string, error) {
+	if len(group.Children) == 0 && len(group.Assignments) == 0 {
+		return nil, nil
+	}
+
+	content, err := scad.FunctionContent(group)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n"), nil
+}
+
+// contentStrings returns the content lines of the Module's definition. Its module and
+// function definitions are written before the rest of its body.
+func (module Module) contentStrings() ([]string, error) {
+	var bodyStrings []string
+
+	for _, nested := range module.Modules {
+		nestedStrings, err := nested.contentStrings()
+		if err != nil {
+			return nil, err
+		}
+		bodyStrings = append(bodyStrings, nestedStrings...)
+	}
+
+	for _, function := range module.Functions {
+		bodyStrings = append(bodyStrings, function.contentString())
+	}
+
+	groupStrings, err := groupContentStrings(module.Body)
+	if err != nil {
+		return nil, err
+	}
+	bodyStrings = append(bodyStrings, groupStrings...)
+
+	mStrings := []string{fmt.Sprintf("module %s(%s) {", module.Name, declarationString(module.Parameters))}
+	for _, bodyString := range bodyStrings {
+		mStrings = append(mStrings, fmt.Sprintf("  %s", bodyString))
+	}
+	mStrings = append(mStrings, "}")
+
+	return mStrings, nil
+}
+
+// contentString returns the content of the FunctionDefinition.
+func (function FunctionDefinition) contentString() string {
+	return fmt.Sprintf("function %s(%s) = %s;", function.Name, declarationString(function.Parameters), function.Expression)
+}
+
+// Content returns the File as OpenSCAD source. Comments and formatting of the original
+// source are not preserved, and statements are written in the order of "use" and "include"
+// statements, module definitions, function definitions, then the top level assignments
+// and statements.
+func (f File) Content() (string, error) {
+	var fStrings []string
+
+	for _, use := range f.Uses {
+		fStrings = append(fStrings, fmt.Sprintf("use <%s>", use))
+	}
+
+	for _, include := range f.Includes {
+		fStrings = append(fStrings, fmt.Sprintf("include <%s>", include))
+	}
+
+	for _, module := range f.Modules {
+		mStrings, err := module.contentStrings()
+		if err != nil {
+			return "", err
+		}
+		fStrings = append(fStrings, mStrings...)
+	}
+
+	for _, function := range f.Functions {
+		fStrings = append(fStrings, function.contentString())
+	}
+
+	rootStrings, err := groupContentStrings(f.Root)
+	if err != nil {
+		return "", err
+	}
+	fStrings = append(fStrings, rootStrings...)
+
+	// trailing newline
+	fStrings = append(fStrings, "")
+
+	return strings.Join(fStrings, "\n"), nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"go.incompletion.ist/go-scad/internal/syntax"
+)
+
+// tokenKind is the kind of a token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenPath
+	tokenPunct
+)
+
+// token is a lexical token of OpenSCAD source.
+type token struct {
+	kind tokenKind
+
+	// text is the token's text. For tokenPath it excludes the surrounding angle brackets.
+	text string
+
+	// start and end are the byte offsets of the token in the source.
+	start int
+	end   int
+
+	pos Position
+}
+
+// is returns a boolean indicating if the token is punctuation or an identifier with the
+// given text.
+func (tok token) is(text string) bool {
+	return (tok.kind == tokenPunct || tok.kind == tokenIdent) && tok.text == text
+}
+
+// String returns a description of the token suitable for error messages.
+func (tok token) String() string {
+	if tok.kind == tokenEOF {
+		return "end of file"
+	}
+
+	return fmt.Sprintf("%q", tok.text)
+}
+
+// multiCharPuncts are the punctuation tokens longer than a single character.
+var multiCharPuncts = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+// lexer splits OpenSCAD source into tokens.
+type lexer struct {
+	filename string
+	src      string
+	offset   int
+	line     int
+	column   int
+}
+
+// position returns the lexer's current Position.
+func (l *lexer) position() Position {
+	return Position{Filename: l.filename, Line: l.line, Column: l.column}
+}
+
+// advance moves the lexer forward by n bytes, tracking lines and columns.
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.offset < len(l.src); i++ {
+		if l.src[l.offset] == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+		l.offset++
+	}
+}
+
+// skipSpace skips whitespace and comments.
+func (l *lexer) skipSpace() error {
+	for l.offset < len(l.src) {
+		rest := l.src[l.offset:]
+
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			end := strings.Index(rest, "\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			l.advance(end)
+		case strings.HasPrefix(rest, "/*"):
+			pos := l.position()
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return &Error{Pos: pos, Msg: "unterminated comment"}
+			}
+			l.advance(end + 4)
+		case strings.ContainsRune(" \t\r\n", rune(rest[0])):
+			l.advance(1)
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// lex returns all tokens of the source, ending with a tokenEOF token.
+func (l *lexer) lex() ([]token, error) {
+	var tokens []token
+
+	for {
+		if err := l.skipSpace(); err != nil {
+			return nil, err
+		}
+
+		tok, err := l.next(tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, tok)
+
+		if tok.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// next returns the token at the lexer's current offset. The previous tokens are used to
+// detect the path following "use" and "include".
+func (l *lexer) next(previous []token) (token, error) {
+	tok := token{start: l.offset, pos: l.position()}
+
+	if l.offset >= len(l.src) {
+		tok.end = l.offset
+		return tok, nil
+	}
+
+	rest := l.src[l.offset:]
+	c := rest[0]
+
+	switch {
+	case c == '<' && len(previous) > 0 && (previous[len(previous)-1].is("use") || previous[len(previous)-1].is("include")):
+		end := strings.IndexAny(rest, ">\n")
+		if end < 0 || rest[end] != '>' {
+			return token{}, &Error{Pos: tok.pos, Msg: "unterminated path"}
+		}
+		tok.kind = tokenPath
+		tok.text = rest[1:end]
+		l.advance(end + 1)
+	case syntax.IsIdentifierStart(c):
+		n := 1
+		for n < len(rest) && syntax.IsIdentifierPart(rest[n]) {
+			n++
+		}
+		tok.kind = tokenIdent
+		tok.text = rest[:n]
+		l.advance(n)
+	case syntax.IsDigit(c) || (c == '.' && len(rest) > 1 && syntax.IsDigit(rest[1])):
+		tok.kind = tokenNumber
+		tok.text = rest[:syntax.NumberLength(rest)]
+		l.advance(len(tok.text))
+	case c == '"':
+		n := 1
+		for ; n < len(rest) && rest[n] != '"'; n++ {
+			if rest[n] == '\\' {
+				n++
+			}
+		}
+		if n >= len(rest) {
+			return token{}, &Error{Pos: tok.pos, Msg: "unterminated string"}
+		}
+		tok.kind = tokenString
+		tok.text = rest[:n+1]
+		l.advance(n + 1)
+	default:
+		tok.kind = tokenPunct
+		tok.text = rest[:1]
+		for _, punct := range multiCharPuncts {
+			if strings.HasPrefix(rest, punct) {
+				tok.text = punct
+				break
+			}
+		}
+		l.advance(len(tok.text))
+	}
+
+	tok.end = l.offset
+
+	return tok, nil
+}
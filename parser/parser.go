@@ -0,0 +1,571 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package parser parses OpenSCAD source into scad.Function trees.
+package parser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// Position is a location within OpenSCAD source.
+type Position struct {
+	// Filename is the name of the parsed file, if known.
+	Filename string
+
+	// Line and Column are 1-based.
+	Line   int
+	Column int
+}
+
+// String returns the Position in the form "filename:line:column", or "line:column" if
+// Filename is empty.
+func (pos Position) String() string {
+	if pos.Filename == "" {
+		return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column)
+}
+
+// Error is a parsing error at a position within OpenSCAD source.
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+// Error returns the error message, prefixed with its position.
+func (err *Error) Error() string {
+	return fmt.Sprintf("parser: %s: %s", err.Pos, err.Msg)
+}
+
+// ParseFile parses the OpenSCAD file at the given path.
+func ParseFile(filename string) (File, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return File{}, err
+	}
+
+	return Parse(filename, src)
+}
+
+// Parse parses OpenSCAD source. The filename is only used for error positions, and
+// may be empty.
+func Parse(filename string, src []byte) (File, error) {
+	l := lexer{filename: filename, src: string(src), line: 1, column: 1}
+
+	tokens, err := l.lex()
+	if err != nil {
+		return File{}, err
+	}
+
+	p := parser{src: l.src, tokens: tokens}
+
+	if err := p.parseFile(); err != nil {
+		return File{}, err
+	}
+
+	return p.file, nil
+}
+
+// scope holds the module and function definitions of a file or module body, which are the
+// only blocks definitions are parsed in.
+type scope struct {
+	modules   *[]Module
+	functions *[]FunctionDefinition
+
+	// isFile indicates the file's scope, which is the only one "use" and "include" statements
+	// are parsed in.
+	isFile bool
+}
+
+// parser builds a File from tokens.
+type parser struct {
+	src    string
+	tokens []token
+	offset int
+
+	file File
+}
+
+// peek returns the token n tokens ahead of the current one, without consuming any.
+func (p *parser) peek(n int) token {
+	if p.offset+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.offset+n]
+}
+
+// next consumes and returns the current token.
+func (p *parser) next() token {
+	tok := p.peek(0)
+	if tok.kind != tokenEOF {
+		p.offset++
+	}
+
+	return tok
+}
+
+// errorf returns an *Error at the given token's position.
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &Error{Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// expect consumes the current token, returning an error if it isn't the given
+// punctuation or keyword.
+func (p *parser) expect(text string) (token, error) {
+	tok := p.next()
+	if !tok.is(text) {
+		return tok, p.errorf(tok, "expected %q, found %s", text, tok)
+	}
+
+	return tok, nil
+}
+
+// expectIdent consumes the current token, returning an error if it isn't an identifier.
+func (p *parser) expectIdent() (token, error) {
+	tok := p.next()
+	if tok.kind != tokenIdent {
+		return tok, p.errorf(tok, "expected identifier, found %s", tok)
+	}
+
+	return tok, nil
+}
+
+// isAssignment returns a boolean indicating if the current tokens are the start of
+// "name = value".
+func (p *parser) isAssignment() bool {
+	return p.peek(0).kind == tokenIdent && p.peek(1).is("=")
+}
+
+// parseFile parses the whole source into p.file.
+func (p *parser) parseFile() error {
+	fileScope := &scope{modules: &p.file.Modules, functions: &p.file.Functions, isFile: true}
+
+	for p.peek(0).kind != tokenEOF {
+		if err := p.parseStatement(&p.file.Root, fileScope); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseBlock parses statements into block until a closing "}", which is consumed. The
+// opening "{" must have already been consumed. Definitions are parsed into sc, which is nil
+// if the block isn't a module body.
+func (p *parser) parseBlock(block *scad.Function, sc *scope) error {
+	for !p.peek(0).is("}") {
+		if p.peek(0).kind == tokenEOF {
+			return p.errorf(p.peek(0), "expected %q, found %s", "}", p.peek(0))
+		}
+
+		if err := p.parseStatement(block, sc); err != nil {
+			return err
+		}
+	}
+	p.next()
+
+	return nil
+}
+
+// parseStatement parses a single statement into block. Module and function definitions are
+// parsed into sc, and are only permitted if it isn't nil. "use" and "include" statements are
+// only permitted in the file's scope.
+func (p *parser) parseStatement(block *scad.Function, sc *scope) error {
+	tok := p.peek(0)
+
+	switch {
+	case tok.is(";"):
+		p.next()
+	case tok.is("{"):
+		p.next()
+
+		var group scad.Function
+		if err := p.parseBlock(&group, nil); err != nil {
+			return err
+		}
+
+		// an empty block has no effect
+		if len(group.Children) > 0 || len(group.Assignments) > 0 {
+			block.Children = append(block.Children, group)
+		}
+	case tok.is("use"), tok.is("include"):
+		if sc == nil || !sc.isFile {
+			return p.errorf(tok, "%s is only supported at the top level", tok)
+		}
+
+		return p.parseDefinition(sc)
+	case tok.is("module"), tok.is("function"):
+		if sc == nil {
+			return p.errorf(tok, "%s is only supported at the top level of a file or module", tok)
+		}
+
+		return p.parseDefinition(sc)
+	case p.isAssignment():
+		assignment, err := p.parseAssignment(";")
+		if err != nil {
+			return err
+		}
+		p.next()
+
+		block.Assignments = append(block.Assignments, assignment)
+	default:
+		fn, err := p.parseInstantiation()
+		if err != nil {
+			return err
+		}
+
+		block.Children = append(block.Children, fn)
+	}
+
+	return nil
+}
+
+// parseDefinition parses a "use" or "include" statement into p.file, or a module or
+// function definition into sc.
+func (p *parser) parseDefinition(sc *scope) error {
+	keyword := p.next()
+
+	switch keyword.text {
+	case "use", "include":
+		path := p.next()
+		if path.kind != tokenPath {
+			return p.errorf(path, "expected <path> after %s, found %s", keyword, path)
+		}
+
+		if keyword.text == "use" {
+			p.file.Uses = append(p.file.Uses, path.text)
+		} else {
+			p.file.Includes = append(p.file.Includes, path.text)
+		}
+	case "module":
+		name, err := p.expectIdent()
+		if err != nil {
+			return err
+		}
+
+		params, err := p.parseDeclarationParameters()
+		if err != nil {
+			return err
+		}
+
+		module := Module{Name: name.text, Parameters: params}
+		moduleScope := &scope{modules: &module.Modules, functions: &module.Functions}
+		if err := p.parseChild(&module.Body, moduleScope); err != nil {
+			return err
+		}
+
+		*sc.modules = append(*sc.modules, module)
+	case "function":
+		name, err := p.expectIdent()
+		if err != nil {
+			return err
+		}
+
+		params, err := p.parseDeclarationParameters()
+		if err != nil {
+			return err
+		}
+
+		if _, err := p.expect("="); err != nil {
+			return err
+		}
+
+		expression, err := p.parseExpression(";")
+		if err != nil {
+			return err
+		}
+		p.next()
+
+		*sc.functions = append(*sc.functions, FunctionDefinition{
+			Name:       name.text,
+			Parameters: params,
+			Expression: expression,
+		})
+	}
+
+	return nil
+}
+
+// parseDeclarationParameters parses the parenthesized parameters of a module or function
+// definition. Parameters without a default value have an empty Value.
+func (p *parser) parseDeclarationParameters() ([]scad.Assignment, error) {
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var params []scad.Assignment
+	for !p.peek(0).is(")") {
+		name, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		param := scad.Assignment{Name: name.text}
+		if p.peek(0).is("=") {
+			p.next()
+
+			param.Value, err = p.parseExpression(",", ")")
+			if err != nil {
+				return nil, err
+			}
+		}
+		params = append(params, param)
+
+		if !p.peek(0).is(")") {
+			if _, err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.next()
+
+	return params, nil
+}
+
+// parseAssignment parses "name = value", leaving the token ending the value unconsumed.
+func (p *parser) parseAssignment(ends ...string) (scad.Assignment, error) {
+	name := p.next()
+	p.next()
+
+	value, err := p.parseExpression(ends...)
+	if err != nil {
+		return scad.Assignment{}, err
+	}
+
+	return scad.Assignment{Name: name.text, Value: value}, nil
+}
+
+// isModifier returns a boolean indicating if the token is a modifier character.
+func isModifier(tok token) bool {
+	return tok.is("#") || tok.is("%") || tok.is("!") || tok.is("*")
+}
+
+// parseInstantiation parses a module instantiation, such as "cube(10);",
+// "translate([1, 0, 0]) { ... }", or "#cube(10);".
+func (p *parser) parseInstantiation() (scad.Function, error) {
+	var modifier scad.Modifier
+	for isModifier(p.peek(0)) {
+		modifier += scad.Modifier(p.next().text)
+	}
+
+	tok := p.peek(0)
+
+	switch {
+	case tok.is("else"):
+		return scad.Function{}, p.errorf(tok, "else without if")
+	case tok.kind != tokenIdent:
+		return scad.Function{}, p.errorf(tok, "expected statement, found %s", tok)
+	}
+
+	fn := scad.Function{Name: p.next().text, Modifier: modifier}
+
+	if _, err := p.expect("("); err != nil {
+		return scad.Function{}, err
+	}
+
+	for !p.peek(0).is(")") {
+		argTok := p.peek(0)
+
+		if p.isAssignment() {
+			param, err := p.parseAssignment(",", ")")
+			if err != nil {
+				return scad.Function{}, err
+			}
+
+			if replaced := fn.SetParameter(param.Name, param.Value); replaced {
+				return scad.Function{}, p.errorf(argTok, "parameter %q set multiple times", param.Name)
+			}
+		} else {
+			arg, err := p.parseExpression(",", ")")
+			if err != nil {
+				return scad.Function{}, err
+			}
+
+			if fn.Parameters != nil {
+				return scad.Function{}, p.errorf(argTok, "positional argument follows named parameters")
+			}
+
+			fn.Arguments = append(fn.Arguments, arg)
+		}
+
+		if !p.peek(0).is(")") {
+			if _, err := p.expect(","); err != nil {
+				return scad.Function{}, err
+			}
+		}
+	}
+	p.next()
+
+	if err := p.parseChild(&fn, nil); err != nil {
+		return scad.Function{}, err
+	}
+
+	if fn.Name == "if" && p.peek(0).is("else") {
+		p.next()
+
+		var elseFn scad.Function
+		if err := p.parseChild(&elseFn, nil); err != nil {
+			return scad.Function{}, err
+		}
+
+		// assignments in the else block need to stay scoped to it
+		if len(elseFn.Assignments) > 0 {
+			fn.Else = []scad.Function{elseFn}
+		} else {
+			fn.Else = elseFn.Children
+		}
+	}
+
+	return fn, nil
+}
+
+// parseChild parses what follows a module instantiation or definition into fn, which is
+// either ";", a block of statements, or a single instantiation. Definitions in a block are
+// parsed into sc, which is nil unless fn is a module body.
+func (p *parser) parseChild(fn *scad.Function, sc *scope) error {
+	tok := p.peek(0)
+
+	switch {
+	case tok.is(";"):
+		p.next()
+	case tok.is("{"):
+		p.next()
+
+		return p.parseBlock(fn, sc)
+	default:
+		child, err := p.parseInstantiation()
+		if err != nil {
+			return err
+		}
+
+		fn.Children = append(fn.Children, child)
+	}
+
+	return nil
+}
+
+// parseExpression consumes the tokens of an expression, returning its source text without
+// comments. The expression ends before the first of the given tokens found outside of
+// brackets, which is not consumed.
+func (p *parser) parseExpression(ends ...string) (string, error) {
+	first := p.peek(0)
+	var tokens []token
+	var depth []token
+
+	for {
+		tok := p.peek(0)
+
+		if tok.kind == tokenEOF {
+			if len(depth) > 0 {
+				return "", p.errorf(depth[len(depth)-1], "unclosed %s", depth[len(depth)-1])
+			}
+
+			return "", p.errorf(tok, "expected %q, found %s", ends[0], tok)
+		}
+
+		if len(depth) == 0 {
+			for _, end := range ends {
+				if tok.is(end) {
+					if tok.start == first.start {
+						return "", p.errorf(tok, "expected expression, found %s", tok)
+					}
+
+					return p.expressionText(tokens), nil
+				}
+			}
+		}
+
+		switch {
+		case tok.is("("), tok.is("["), tok.is("{"):
+			depth = append(depth, tok)
+		case tok.is(")"), tok.is("]"), tok.is("}"):
+			if len(depth) == 0 || !closes(depth[len(depth)-1].text, tok.text) {
+				return "", p.errorf(tok, "unexpected %s", tok)
+			}
+			depth = depth[:len(depth)-1]
+		case tok.is(";") && len(depth) > 0:
+			return "", p.errorf(tok, "unexpected %s", tok)
+		}
+
+		tokens = append(tokens, p.next())
+	}
+}
+
+// expressionText returns the source text of an expression's tokens. The space between
+// tokens is kept, except where it holds a comment, which is replaced along with its space by
+// a single space, or removed if it isn't separated from the tokens by any space.
+func (p *parser) expressionText(tokens []token) string {
+	var text strings.Builder
+
+	for i, tok := range tokens {
+		if i > 0 {
+			// only whitespace and comments are skipped between tokens, so a slash starts a comment
+			gap := p.src[tokens[i-1].end:tok.start]
+			if strings.Contains(gap, "/") {
+				gap = ""
+				if hasSpaceOutsideComments(p.src[tokens[i-1].end:tok.start]) {
+					gap = " "
+				}
+			}
+
+			text.WriteString(gap)
+		}
+
+		text.WriteString(p.src[tok.start:tok.end])
+	}
+
+	return text.String()
+}
+
+// hasSpaceOutsideComments returns a boolean indicating if the whitespace and comments
+// between two tokens have any whitespace outside of the comments.
+func hasSpaceOutsideComments(gap string) bool {
+	for gap != "" {
+		switch {
+		case strings.HasPrefix(gap, "//"):
+			end := strings.Index(gap, "\n")
+			if end < 0 {
+				end = len(gap)
+			}
+			gap = gap[end:]
+		case strings.HasPrefix(gap, "/*"):
+			gap = gap[strings.Index(gap[2:], "*/")+4:]
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// closes returns a boolean indicating if the closing bracket matches the opening one.
+func closes(opening, closing string) bool {
+	switch opening {
+	case "(":
+		return closing == ")"
+	case "[":
+		return closing == "]"
+	case "{":
+		return closing == "}"
+	}
+
+	return false
+}
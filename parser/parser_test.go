@@ -0,0 +1,425 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  File
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  File{},
+		},
+		{
+			name:  "comments only",
+			input: "// line\n/* block\ncomment */",
+			want:  File{},
+		},
+		{
+			name:  "use and include",
+			input: "use <lib/bolts.scad>\ninclude <consts.scad>",
+			want: File{
+				Uses:     []string{"lib/bolts.scad"},
+				Includes: []string{"consts.scad"},
+			},
+		},
+		{
+			name:  "call with arguments and parameters",
+			input: "cube([1, 2, 3], center=true);",
+			want: File{
+				Root: scad.Function{
+					Children: []scad.Function{
+						{
+							Name:       "cube",
+							Arguments:  []string{"[1, 2, 3]"},
+							Parameters: map[string]string{"center": "true"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "expressions with brackets and strings",
+			input: `text(str("a,", len([1, 2])), size=f(1, 2)[0]);`,
+			want: File{
+				Root: scad.Function{
+					Children: []scad.Function{
+						{
+							Name:       "text",
+							Arguments:  []string{`str("a,", len([1, 2]))`},
+							Parameters: map[string]string{"size": "f(1, 2)[0]"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "single child and block children",
+			input: "translate([1, 0, 0]) rotate(90) cube(1);\nunion() { cube(1); sphere(1); }",
+			want: File{
+				Root: scad.Function{
+					Children: []scad.Function{
+						{
+							Name:      "translate",
+							Arguments: []string{"[1, 0, 0]"},
+							Children: []scad.Function{
+								{
+									Name:      "rotate",
+									Arguments: []string{"90"},
+									Children: []scad.Function{
+										{Name: "cube", Arguments: []string{"1"}},
+									},
+								},
+							},
+						},
+						{
+							Name: "union",
+							Children: []scad.Function{
+								{Name: "cube", Arguments: []string{"1"}},
+								{Name: "sphere", Arguments: []string{"1"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "assignments",
+			input: "$fn = 64;\nwall = 2 * 1.5;\ntranslate([wall, 0, 0]) { size = wall * 2; cube(size); }",
+			want: File{
+				Root: scad.Function{
+					Assignments: []scad.Assignment{
+						{Name: "$fn", Value: "64"},
+						{Name: "wall", Value: "2 * 1.5"},
+					},
+					Children: []scad.Function{
+						{
+							Name:        "translate",
+							Arguments:   []string{"[wall, 0, 0]"},
+							Assignments: []scad.Assignment{{Name: "size", Value: "wall * 2"}},
+							Children: []scad.Function{
+								{Name: "cube", Arguments: []string{"size"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "module and function definitions",
+			input: "module box(size, center=false) cube(size, center=center);\nfunction double(x) = x * 2;",
+			want: File{
+				Modules: []Module{
+					{
+						Name: "box",
+						Parameters: []scad.Assignment{
+							{Name: "size"},
+							{Name: "center", Value: "false"},
+						},
+						Body: scad.Function{
+							Children: []scad.Function{
+								{
+									Name:       "cube",
+									Arguments:  []string{"size"},
+									Parameters: map[string]string{"center": "center"},
+								},
+							},
+						},
+					},
+				},
+				Functions: []FunctionDefinition{
+					{
+						Name:       "double",
+						Parameters: []scad.Assignment{{Name: "x"}},
+						Expression: "x * 2",
+					},
+				},
+			},
+		},
+		{
+			name:  "nested module and function definitions",
+			input: "module box(size) {\n  function half(x) = x / 2;\n  module wall() cube([size, 1, half(size)]);\n  wall();\n}",
+			want: File{
+				Modules: []Module{
+					{
+						Name:       "box",
+						Parameters: []scad.Assignment{{Name: "size"}},
+						Modules: []Module{
+							{
+								Name: "wall",
+								Body: scad.Function{
+									Children: []scad.Function{
+										{Name: "cube", Arguments: []string{"[size, 1, half(size)]"}},
+									},
+								},
+							},
+						},
+						Functions: []FunctionDefinition{
+							{
+								Name:       "half",
+								Parameters: []scad.Assignment{{Name: "x"}},
+								Expression: "x / 2",
+							},
+						},
+						Body: scad.Function{
+							Children: []scad.Function{{Name: "wall"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "comments in expressions",
+			input: "wall = 2 /* mm */ + 1;\ncube([10, // width\n  20], center=/*centered*/true);\nh = 2*/*twice*/wall;",
+			want: File{
+				Root: scad.Function{
+					Assignments: []scad.Assignment{
+						{Name: "wall", Value: "2 + 1"},
+						{Name: "h", Value: "2*wall"},
+					},
+					Children: []scad.Function{
+						{
+							Name:       "cube",
+							Arguments:  []string{"[10, 20]"},
+							Parameters: map[string]string{"center": "true"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "modifiers",
+			input: "difference() { cube(10); #translate([1, 1, 1]) cube(8); }\n!*%sphere(1);",
+			want: File{
+				Root: scad.Function{
+					Children: []scad.Function{
+						{
+							Name: "difference",
+							Children: []scad.Function{
+								{Name: "cube", Arguments: []string{"10"}},
+								{
+									Name:      "translate",
+									Arguments: []string{"[1, 1, 1]"},
+									Modifier:  scad.Highlight,
+									Children: []scad.Function{
+										{Name: "cube", Arguments: []string{"8"}},
+									},
+								},
+							},
+						},
+						{Name: "sphere", Arguments: []string{"1"}, Modifier: "!*%"},
+					},
+				},
+			},
+		},
+		{
+			name:  "for loop",
+			input: "for (i = [0:2]) translate([i, 0, 0]) cube(1);",
+			want: File{
+				Root: scad.Function{
+					Children: []scad.Function{
+						{
+							Name:       "for",
+							Parameters: map[string]string{"i": "[0:2]"},
+							Children: []scad.Function{
+								{
+									Name:      "translate",
+									Arguments: []string{"[i, 0, 0]"},
+									Children: []scad.Function{
+										{Name: "cube", Arguments: []string{"1"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "if and else",
+			input: "if (round) sphere(1); else { cube(2); }\nif (a) cube(1); else if (b) sphere(1);",
+			want: File{
+				Root: scad.Function{
+					Children: []scad.Function{
+						{
+							Name:      "if",
+							Arguments: []string{"round"},
+							Children:  []scad.Function{{Name: "sphere", Arguments: []string{"1"}}},
+							Else:      []scad.Function{{Name: "cube", Arguments: []string{"2"}}},
+						},
+						{
+							Name:      "if",
+							Arguments: []string{"a"},
+							Children:  []scad.Function{{Name: "cube", Arguments: []string{"1"}}},
+							Else: []scad.Function{
+								{
+									Name:      "if",
+									Arguments: []string{"b"},
+									Children:  []scad.Function{{Name: "sphere", Arguments: []string{"1"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Parse("", []byte(test.input))
+		if err != nil {
+			t.Errorf("%q Parse() returned error: %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q Parse() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantPos Position
+	}{
+		{
+			name:    "missing semicolon",
+			input:   "cube(1)",
+			wantPos: Position{Filename: "test.scad", Line: 1, Column: 8},
+		},
+		{
+			name:    "unclosed block",
+			input:   "union() {\n  cube(1);\n",
+			wantPos: Position{Filename: "test.scad", Line: 3, Column: 1},
+		},
+		{
+			name:    "unclosed bracket",
+			input:   "cube([1, 2, 3);",
+			wantPos: Position{Filename: "test.scad", Line: 1, Column: 14},
+		},
+		{
+			name:    "unterminated string",
+			input:   "text(\n  \"abc);",
+			wantPos: Position{Filename: "test.scad", Line: 2, Column: 3},
+		},
+		{
+			name:    "module definition in block",
+			input:   "union() {\n  module inner() cube(1);\n}",
+			wantPos: Position{Filename: "test.scad", Line: 2, Column: 3},
+		},
+		{
+			name:    "module definition in module block",
+			input:   "module outer() union() {\n  module inner() cube(1);\n}",
+			wantPos: Position{Filename: "test.scad", Line: 2, Column: 3},
+		},
+		{
+			name:    "use in module",
+			input:   "module outer() {\n  use <lib.scad>\n}",
+			wantPos: Position{Filename: "test.scad", Line: 2, Column: 3},
+		},
+		{
+			name:    "positional after named",
+			input:   "cube(center=true, 10);",
+			wantPos: Position{Filename: "test.scad", Line: 1, Column: 19},
+		},
+		{
+			name:    "modifier without instantiation",
+			input:   "union() { # }",
+			wantPos: Position{Filename: "test.scad", Line: 1, Column: 13},
+		},
+		{
+			name:    "else without if",
+			input:   "cube(1);\nelse cube(2);",
+			wantPos: Position{Filename: "test.scad", Line: 2, Column: 1},
+		},
+		{
+			name:    "missing expression",
+			input:   "wall = ;",
+			wantPos: Position{Filename: "test.scad", Line: 1, Column: 8},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Parse("test.scad", []byte(test.input))
+
+		var parseErr *Error
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q Parse() got error %v, want *Error", test.name, err)
+			continue
+		}
+
+		if parseErr.Pos != test.wantPos {
+			t.Errorf("%q Parse() got error at %s, want %s (%s)", test.name, parseErr.Pos, test.wantPos, err)
+		}
+	}
+}
+
+func TestFile_Content_roundTrip(t *testing.T) {
+	input := `
+use <lib/bolts.scad>
+
+$fn = 64;
+wall = 2;
+
+module box(size, center=false) {
+  function half(x) = x / 2;
+  module lid() translate([0, 0, size]) cube([size, size, half(wall)]);
+  inner = size - 2*wall; // hollow
+  lid();
+  difference() {
+    cube(size, center=center);
+    translate([wall, wall, wall]) cube([inner, inner, size]);
+  }
+}
+
+function double(x) = x * 2;
+
+#box(double(10));
+if (wall > 1) box(10); else { thin = true; box(5); }
+for (i = [0:2]) translate([i*30, 0, 0]) box(size=20, center=true);
+`
+
+	parsed, err := Parse("", []byte(input))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %s", err)
+	}
+
+	content, err := parsed.Content()
+	if err != nil {
+		t.Fatalf("Content() returned error: %s", err)
+	}
+
+	reparsed, err := Parse("", []byte(content))
+	if err != nil {
+		t.Fatalf("Parse() of Content() returned error: %s\n%s", err, content)
+	}
+
+	if !reflect.DeepEqual(parsed, reparsed) {
+		t.Errorf("Parse() of Content() got\n%#v, want\n%#v", reparsed, parsed)
+	}
+}
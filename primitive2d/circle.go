@@ -19,8 +19,8 @@ import "go.incompletion.ist/go-scad/value"
 // Circle is a circle.
 type Circle struct {
 	// Only one of R or D should be set.
-	R value.Float `scad:"r"`
-	D value.Float `scad:"d"`
+	R value.Float `scad:"r,oneof=r,min=0"`
+	D value.Float `scad:"d,oneof=r,min=0"`
 
 	FA value.Float `scad:"$fa"`
 	FS value.Float `scad:"$fs"`
@@ -14,3 +14,17 @@
 
 // Package primitve2d provides OpenSCAD 2D primitive types.
 package primitive2d
+
+import (
+	"reflect"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// init registers the types of this package to be decoded by scad.Decode.
+func init() {
+	scad.RegisterType("circle", reflect.TypeOf(Circle{}))
+	scad.RegisterType("polygon", reflect.TypeOf(Polygon{}))
+	scad.RegisterType("square", reflect.TypeOf(Square{}))
+	scad.RegisterType("text", reflect.TypeOf(Text{}))
+}
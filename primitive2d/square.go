@@ -21,8 +21,8 @@ import (
 // Square is a square.
 type Square struct {
 	// Only one of Size or SizeXY should be set.
-	Size   value.Float `scad:"size"`
-	SizeXY value.Float `scad:"size"`
+	Size   value.Float `scad:"size,oneof=size,min=0"`
+	SizeXY value.Float `scad:"size,oneof=size,min=0"`
 
 	Center value.Bool
 }
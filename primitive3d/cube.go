@@ -21,8 +21,8 @@ import (
 // Cube is a cube.
 type Cube struct {
 	// Only one of these size values may be set.
-	Size    value.Float    `scad:"size"`
-	SizeXYZ value.FloatXYZ `scad:"size"`
+	Size    value.Float    `scad:"size,oneof=size,min=0"`
+	SizeXYZ value.FloatXYZ `scad:"size,oneof=size,min=0"`
 
 	Center value.Bool
 }
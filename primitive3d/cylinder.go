@@ -20,13 +20,13 @@ import (
 
 // Cylinder is a cylinder.
 type Cylinder struct {
-	H      value.Float `scad:"h"`
-	R      value.Float `scad:"r"`
-	R1     value.Float `scad:"r1"`
-	R2     value.Float `scad:"r2"`
-	D      value.Float `scad:"d"`
-	D1     value.Float `scad:"d1"`
-	D2     value.Float `scad:"d2"`
+	H      value.Float `scad:"h,min=0"`
+	R      value.Float `scad:"r,oneof=r,min=0"`
+	R1     value.Float `scad:"r1,oneof=r1,min=0"`
+	R2     value.Float `scad:"r2,oneof=r2,min=0"`
+	D      value.Float `scad:"d,oneof=r,min=0"`
+	D1     value.Float `scad:"d1,oneof=r1,min=0"`
+	D2     value.Float `scad:"d2,oneof=r2,min=0"`
 	Center value.Bool  `scad:"center"`
 
 	FA value.Float `scad:"$fa"`
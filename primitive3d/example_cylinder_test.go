@@ -31,5 +31,13 @@ func ExampleCylinder() {
 
 	content, _ := scad.FunctionContent(cylinder)
 	fmt.Println(content)
+
+	// only one of R1 or D1 may be set
+	cylinder.R1 = value.NewFloat(10)
+
+	_, err := scad.FunctionContent(cylinder)
+	fmt.Println(err)
 	// Output: cylinder(d1=20, d2=5, h=50);
+	//
+	// Cylinder: scad: attempted to encode type (primitive3d.Cylinder) with multiple fields of oneof group "r1" set: R1, D1
 }
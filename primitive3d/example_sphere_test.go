@@ -0,0 +1,41 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package primitive3d_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/value"
+)
+
+func ExampleSphere() {
+	sphere := primitive3d.Sphere{
+		D: value.NewFloat(10),
+	}
+
+	content, _ := scad.FunctionContent(sphere)
+	fmt.Println(content)
+
+	// only one of R or D may be set
+	sphere.R = value.NewFloat(5)
+
+	_, err := scad.FunctionContent(sphere)
+	fmt.Println(err)
+	// Output: sphere(d=10);
+	//
+	// Sphere: scad: attempted to encode type (primitive3d.Sphere) with multiple fields of oneof group "r" set: R, D
+}
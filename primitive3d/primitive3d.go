@@ -14,3 +14,16 @@
 
 // Package primitive3d provides OpenSCAD 3D primitive types.
 package primitive3d
+
+import (
+	"reflect"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// init registers the types of this package to be decoded by scad.Decode.
+func init() {
+	scad.RegisterType("cube", reflect.TypeOf(Cube{}))
+	scad.RegisterType("cylinder", reflect.TypeOf(Cylinder{}))
+	scad.RegisterType("sphere", reflect.TypeOf(Sphere{}))
+}
@@ -21,8 +21,8 @@ import (
 // Sphere is a sphere.
 type Sphere struct {
 	// Only one of R or D should be set.
-	R value.Float `scad:"r"`
-	D value.Float `scad:"d"`
+	R value.Float `scad:"r,oneof=r,min=0"`
+	D value.Float `scad:"d,oneof=r,min=0"`
 
 	FA value.Float `scad:"$fa"`
 	FS value.Float `scad:"$fs"`
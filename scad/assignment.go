@@ -0,0 +1,72 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import "reflect"
+
+// Assignment describes a SCAD variable assignment, such as "wall = 2;".
+type Assignment struct {
+	// Name is the variable name, such as "wall" or "$fn".
+	Name string
+
+	// Value is the variable value in string form.
+	Value string
+}
+
+// Variable is a variable to be assigned when encoding. Its Value is converted to string form
+// the same way as a module parameter's value, so it may be a ParameterValueGetter or any of
+// Go's basic types. Variables with unset values are not assigned.
+type Variable struct {
+	Name  string
+	Value interface{}
+}
+
+// Variables is an ordered slice of Variable. Its presence in a struct causes its Variables
+// to be assigned at the start of the encoded Function's scope, or at the top of the file
+// containing the Function if the field has the "file" option in its "scad" tag.
+type Variables []Variable
+
+// GetVariables returns the Variables. It satisfies the VariablesGetter interface.
+func (variables Variables) GetVariables() []Variable {
+	return variables
+}
+
+// Assignments returns the Assignments for the set values of the Variables, in order.
+func (variables Variables) Assignments() ([]Assignment, error) {
+	return variablesAssignments(variables, Encoder{})
+}
+
+// variablesAssignments returns the Assignments for the set values of the given Variables,
+// using the parameter encoders of an Encoder.
+func variablesAssignments(variables []Variable, enc Encoder) ([]Assignment, error) {
+	var assignments []Assignment
+
+	for _, variable := range variables {
+		if variable.Value == nil {
+			continue
+		}
+
+		value, ok, err := parameterValue(reflect.ValueOf(variable.Value), enc)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			assignments = append(assignments, Assignment{Name: variable.Name, Value: value})
+		}
+	}
+
+	return assignments, nil
+}
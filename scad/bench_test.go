@@ -0,0 +1,108 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"testing"
+)
+
+type benchCube struct {
+	cube AutoFunctionName //nolint:golint,structcheck,unused
+
+	Size   testParameterValueGetter
+	Center testParameterValueGetter
+}
+
+type benchTranslate struct {
+	translate AutoFunctionName //nolint:golint,structcheck,unused
+
+	V        testParameterValueGetter `scad:"v"`
+	Modifier Modifier
+	Children []interface{}
+}
+
+// benchLattice returns a lattice of size³ cubes, each translated within nested translated
+// layers and rows.
+func benchLattice(size int) benchTranslate {
+	set := func(value string) testParameterValueGetter {
+		return testParameterValueGetter{value: value, explicit: true}
+	}
+
+	lattice := benchTranslate{V: set("[0, 0, 0]")}
+
+	for z := 0; z < size; z++ {
+		layer := benchTranslate{V: set(fmt.Sprintf("[0, 0, %d]", z))}
+
+		for y := 0; y < size; y++ {
+			row := benchTranslate{V: set(fmt.Sprintf("[0, %d, 0]", y))}
+
+			for x := 0; x < size; x++ {
+				row.Children = append(row.Children, benchTranslate{
+					V:        set(fmt.Sprintf("[%d, 0, 0]", x)),
+					Children: []interface{}{benchCube{Size: set("0.5"), Center: set("true")}},
+				})
+			}
+
+			layer.Children = append(layer.Children, row)
+		}
+
+		lattice.Children = append(lattice.Children, layer)
+	}
+
+	return lattice
+}
+
+func BenchmarkEncode(b *testing.B) {
+	lattice := benchLattice(20)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Encode(lattice); err != nil {
+			b.Fatalf("Encode() returned error: %s", err)
+		}
+	}
+}
+
+func BenchmarkFunction_flatContent(b *testing.B) {
+	fn, err := Encode(benchLattice(20))
+	if err != nil {
+		b.Fatalf("Encode() returned error: %s", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := (Format{}).FlatContent(fn); err != nil {
+			b.Fatalf("FlatContent() returned error: %s", err)
+		}
+	}
+}
+
+func BenchmarkFunctionContent(b *testing.B) {
+	lattice := benchLattice(20)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := FunctionContent(lattice); err != nil {
+			b.Fatalf("FunctionContent() returned error: %s", err)
+		}
+	}
+}
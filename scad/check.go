@@ -0,0 +1,110 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// fieldValue returns the string form of a struct field's value, and a boolean indicating if
+// it is set. Slices without a parameter encoder are set if they aren't empty, and single
+// child fields if they aren't nil, and neither have a string form. Other values are found
+// by parameterValue, but are unset if the field has the "omitempty" option in its "scad"
+// tag and holds its type's zero value.
+func fieldValue(fieldV reflect.Value, opts tagOptions, enc Encoder) (string, bool, error) {
+	if fieldV.Kind() == reflect.Slice && !enc.hasParameterEncoder(fieldV.Type()) {
+		return "", fieldV.Len() > 0, nil
+	}
+
+	if opts.has("child") {
+		isNil := (fieldV.Kind() == reflect.Ptr || fieldV.Kind() == reflect.Interface) && fieldV.IsNil()
+
+		return "", !isNil, nil
+	}
+
+	if opts.has("omitempty") && fieldV.IsZero() {
+		return "", false, nil
+	}
+
+	return parameterValue(fieldV, enc)
+}
+
+// fieldChecker checks the "required", "oneof", and "min" options of the "scad" tags of a
+// struct type's fields.
+type fieldChecker struct {
+	// t is the struct type being checked.
+	t reflect.Type
+
+	// oneofFields is the name of the set field of each oneof group.
+	oneofFields map[string]string
+
+	// encoder is the Encoder whose parameter encoders find the fields' values.
+	encoder Encoder
+}
+
+// hasChecks returns a boolean indicating if any of the checked options are present.
+func (opts tagOptions) hasChecks() bool {
+	_, hasOneof := opts.value("oneof")
+	_, hasMin := opts.value("min")
+
+	return opts.has("required") || hasOneof || hasMin
+}
+
+// check returns an error if a field's value violates its "required", "oneof", or "min"
+// options.
+func (checker *fieldChecker) check(field reflect.StructField, fieldV reflect.Value, opts tagOptions) error {
+	value, ok, err := fieldValue(fieldV, opts, checker.encoder)
+	if err != nil {
+		return err
+	}
+
+	if opts.has("required") && !ok {
+		return fmt.Errorf("scad: attempted to encode type (%s) without required field: %s", checker.t, field.Name)
+	}
+
+	if group, hasOneof := opts.value("oneof"); hasOneof && ok {
+		if setField, ok := checker.oneofFields[group]; ok {
+			return fmt.Errorf("scad: attempted to encode type (%s) with multiple fields of oneof group %q set: %s, %s", checker.t, group, setField, field.Name)
+		}
+
+		if checker.oneofFields == nil {
+			checker.oneofFields = map[string]string{}
+		}
+		checker.oneofFields[group] = field.Name
+	}
+
+	if minString, hasMin := opts.value("min"); hasMin {
+		min, err := strconv.ParseFloat(minString, 64)
+		if err != nil {
+			return fmt.Errorf("scad: attempted to encode type (%s) with invalid min option for field %s: %q", checker.t, field.Name, minString)
+		}
+
+		// values that aren't literal numbers, such as expressions, can't be checked
+		numbers, _, isNumeric := parseNumbers(value)
+		if !ok || !isNumeric {
+			return nil
+		}
+
+		for _, number := range numbers {
+			if number < min {
+				return fmt.Errorf("scad: attempted to encode type (%s) with field %s value %s less than min %s", checker.t, field.Name, value, minString)
+			}
+		}
+	}
+
+	return nil
+}
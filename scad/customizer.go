@@ -0,0 +1,221 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CustomizerParameter describes how a module parameter is presented in OpenSCAD's Customizer.
+type CustomizerParameter struct {
+	// Name is the name of the module parameter.
+	Name string
+
+	// Description is shown in the Customizer with the parameter. It may be empty.
+	Description string
+
+	// Group is the Customizer tab the parameter is shown in. Parameters without a Group are
+	// shown before any grouped parameters.
+	Group string
+
+	// Widget is the Customizer annotation for the parameter, such as "[20:1:100]" for a slider
+	// or "[S:Small, L:Large]" for a dropdown. It may be empty.
+	Widget string
+}
+
+// customizerParameter returns the CustomizerParameter for a struct field with the "customize"
+// option in its "scad" tag. Its Widget is built from the "range" ("min:max"), "step", and
+// "options" ("a|b|c") tag options, and its Description is the value of the field's
+// "description" tag.
+func customizerParameter(name string, field reflect.StructField, opts tagOptions) (CustomizerParameter, error) {
+	parameter := CustomizerParameter{
+		Name:        name,
+		Description: field.Tag.Get("description"),
+	}
+	parameter.Group, _ = opts.value("group")
+
+	rangeValue, hasRange := opts.value("range")
+	stepValue, hasStep := opts.value("step")
+	optionsValue, hasOptions := opts.value("options")
+
+	switch {
+	case hasRange && hasOptions:
+		return CustomizerParameter{}, fmt.Errorf("scad: customizer field %s has both range and options", field.Name)
+	case hasStep && !hasRange:
+		return CustomizerParameter{}, fmt.Errorf("scad: customizer field %s has step without range", field.Name)
+	case hasRange:
+		bounds := strings.Split(rangeValue, ":")
+		if len(bounds) != 2 || bounds[0] == "" || bounds[1] == "" {
+			return CustomizerParameter{}, fmt.Errorf("scad: customizer field %s has invalid range: %s", field.Name, rangeValue)
+		}
+
+		if hasStep {
+			parameter.Widget = fmt.Sprintf("[%s:%s:%s]", bounds[0], stepValue, bounds[1])
+		} else {
+			parameter.Widget = fmt.Sprintf("[%s]", rangeValue)
+		}
+	case hasOptions:
+		parameter.Widget = fmt.Sprintf("[%s]", strings.Join(strings.Split(optionsValue, "|"), ", "))
+	}
+
+	return parameter, nil
+}
+
+// customizerAssignmentStrings returns the top-level variable assignments for the Function's
+// CustomizerParameters, annotated for OpenSCAD's Customizer, with the numbers of their values
+// formatted. Ungrouped parameters come first, followed by each group in the order it first
+// appears. Parameters without a ModuleParameters value are skipped.
+func (fn Function) customizerAssignmentStrings(format Format) []string {
+	var groups []string
+	groupParameters := map[string][]CustomizerParameter{}
+
+	for _, parameter := range fn.CustomizerParameters {
+		if _, ok := fn.ModuleParameters[parameter.Name]; !ok {
+			continue
+		}
+
+		if _, ok := groupParameters[parameter.Group]; !ok && parameter.Group != "" {
+			groups = append(groups, parameter.Group)
+		}
+
+		groupParameters[parameter.Group] = append(groupParameters[parameter.Group], parameter)
+	}
+
+	var cStrings []string
+
+	for _, group := range append([]string{""}, groups...) {
+		if group != "" {
+			cStrings = append(cStrings, fmt.Sprintf("/* [%s] */", group))
+		}
+
+		for _, parameter := range groupParameters[group] {
+			if parameter.Description != "" {
+				cStrings = append(cStrings, fmt.Sprintf("// %s", parameter.Description))
+			}
+
+			assignmentString := fmt.Sprintf("%s = %s;", parameter.Name, format.value(fn.ModuleParameters[parameter.Name]))
+			if parameter.Widget == "" {
+				cStrings = append(cStrings, assignmentString)
+			} else {
+				cStrings = append(cStrings, fmt.Sprintf("%s // %s", assignmentString, parameter.Widget))
+			}
+		}
+	}
+
+	return cStrings
+}
+
+// customizerArgumentsString returns the arguments passing each customizable module parameter
+// its top-level variable of the same name, in the Function's ParameterOrder.
+func (fn Function) customizerArgumentsString() string {
+	arguments := map[string]string{}
+
+	for _, parameter := range fn.CustomizerParameters {
+		if _, ok := fn.ModuleParameters[parameter.Name]; ok {
+			arguments[parameter.Name] = parameter.Name
+		}
+	}
+
+	keys := orderedKeys(arguments, fn.ParameterOrder)
+	for i, key := range keys {
+		keys[i] = key + "=" + key
+	}
+
+	return strings.Join(keys, ", ")
+}
+
+// customizerParameterSet returns the Customizer parameter set values for the Function's
+// customizable module parameters.
+func (fn Function) customizerParameterSet() map[string]string {
+	set := map[string]string{}
+
+	for _, parameter := range fn.CustomizerParameters {
+		value, ok := fn.ModuleParameters[parameter.Name]
+		if !ok {
+			continue
+		}
+
+		// the Customizer stores strings unquoted
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		set[parameter.Name] = value
+	}
+
+	return set
+}
+
+// ParameterSets is the content of an OpenSCAD Customizer parameter set file.
+type ParameterSets struct {
+	// ParameterSets is a map of parameter set names to their parameter values.
+	ParameterSets map[string]map[string]string `json:"parameterSets"`
+
+	// FileFormatVersion is the version of the parameter set file format.
+	FileFormatVersion string `json:"fileFormatVersion"`
+}
+
+// EncodeParameterSets encodes each sample, keyed by its parameter set name, and returns the
+// Function of the first sample (by name) along with the ParameterSets of the samples'
+// customizable module parameters. Every sample must encode to the same module, with a
+// definition that differs only by the values of its module parameters.
+func EncodeParameterSets(samples map[string]interface{}) (Function, ParameterSets, error) {
+	return Encoder{}.EncodeParameterSets(samples)
+}
+
+// EncodeParameterSets encodes each sample, keyed by its parameter set name, as described by
+// the EncodeParameterSets function.
+func (enc Encoder) EncodeParameterSets(samples map[string]interface{}) (Function, ParameterSets, error) {
+	names := make([]string, 0, len(samples))
+	for name := range samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fn Function
+	sets := ParameterSets{
+		ParameterSets:     map[string]map[string]string{},
+		FileFormatVersion: "1",
+	}
+
+	for i, name := range names {
+		sampleFn, err := enc.Encode(samples[name])
+		if err != nil {
+			return Function{}, ParameterSets{}, err
+		}
+
+		if sampleFn.ModuleName == "" {
+			return Function{}, ParameterSets{}, fmt.Errorf("scad: parameter set sample %s is not a module", name)
+		}
+
+		switch {
+		case i == 0:
+			fn = sampleFn
+		case sampleFn.ModuleName != fn.ModuleName:
+			return Function{}, ParameterSets{}, fmt.Errorf("scad: parameter set sample %s has module %s, want %s", name, sampleFn.ModuleName, fn.ModuleName)
+		case !reflect.DeepEqual(sampleFn.moduleDefinition(), fn.moduleDefinition()):
+			// the parameter sets only hold the module parameters, so they can't describe any other difference
+			return Function{}, ParameterSets{}, fmt.Errorf("scad: parameter set sample %s has a definition of module %s that differs from sample %s", name, fn.ModuleName, names[0])
+		}
+
+		sets.ParameterSets[name] = sampleFn.customizerParameterSet()
+	}
+
+	return fn, sets, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testParameterSetBlock is a module whose definition depends on a field that isn't one of
+// its parameters.
+type testParameterSetBlock struct {
+	block ModuleName `scad:"block"` //nolint:golint,structcheck,unused
+
+	Width   int  `scad:"width,customize"`
+	Rounded bool `scad:"-"`
+}
+
+func (block testParameterSetBlock) EncodeSCAD() (interface{}, error) {
+	if block.Rounded {
+		return Function{Name: "sphere", Parameters: map[string]string{"d": "width"}}, nil
+	}
+
+	return Function{Name: "cube", Parameters: map[string]string{"size": "width"}}, nil
+}
+
+func TestEncodeParameterSets(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     map[string]interface{}
+		want      map[string]map[string]string
+		wantError bool
+	}{
+		{
+			name: "same definition",
+			input: map[string]interface{}{
+				"small": testParameterSetBlock{Width: 10},
+				"large": testParameterSetBlock{Width: 20},
+			},
+			want: map[string]map[string]string{
+				"small": {"width": "10"},
+				"large": {"width": "20"},
+			},
+		},
+		{
+			name: "different definitions",
+			input: map[string]interface{}{
+				"small": testParameterSetBlock{Width: 10},
+				"large": testParameterSetBlock{Width: 20, Rounded: true},
+			},
+			wantError: true,
+		},
+		{
+			name: "different modules",
+			input: map[string]interface{}{
+				"small": testParameterSetBlock{Width: 10},
+				"large": Function{ModuleName: "other", Name: "cube"},
+			},
+			wantError: true,
+		},
+		{
+			name: "not a module",
+			input: map[string]interface{}{
+				"small": Function{Name: "cube"},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		_, got, err := EncodeParameterSets(test.input)
+		gotError := err != nil
+
+		if gotError != test.wantError {
+			t.Errorf("%q EncodeParameterSets() returned error? %v (%s)", test.name, gotError, err)
+		}
+
+		if gotError {
+			continue
+		}
+
+		if !reflect.DeepEqual(got.ParameterSets, test.want) {
+			t.Errorf("%q EncodeParameterSets() got\n%#v, want\n%#v", test.name, got.ParameterSets, test.want)
+		}
+	}
+}
@@ -0,0 +1,459 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// decodeTypes is the global registry of struct types by the Function name they decode.
+var decodeTypes sync.Map // map[string]reflect.Type
+
+// functionType is the type of Function, which child fields may hold undecoded.
+var functionType = reflect.TypeOf(Function{})
+
+// RegisterType registers a struct type to be decoded from Functions with the given name,
+// which is the ModuleName of modules, and the Name of other Functions. The types of this
+// module's packages register themselves. Registering a nil t removes the registration.
+func RegisterType(name string, t reflect.Type) {
+	if t == nil {
+		decodeTypes.Delete(name)
+
+		return
+	}
+
+	decodeTypes.Store(name, t)
+}
+
+// Decoder decodes Functions to the struct types they were encoded from. Its types are
+// consulted before those registered globally, so types can be decoded without registering
+// them for the whole program. The zero value decodes the same as Decode.
+type Decoder struct {
+	// Types are the struct types by the Function name they decode, as registered globally
+	// by RegisterType.
+	Types map[string]reflect.Type
+}
+
+// Decode decodes a Function, such as one parsed from a file, to a value of the struct type
+// registered for its name, as described by Decoder.Decode.
+func Decode(fn Function) (interface{}, error) {
+	return Decoder{}.Decode(fn)
+}
+
+// Decode decodes a Function to a value of the struct type registered for its name, which is
+// the inverse of Encode. Fields are matched to the Function's values by the same "scad" tags
+// that Encode uses:
+//
+// • Parameters, and Arguments of OpenSCAD's builtin modules, are set on ParameterValueSetter
+// fields, or fields of Go's basic types, by their names. If multiple fields have the same
+// name, such as the "oneof" fields of primitive3d.Cube, the first that accepts the value is
+// set.
+//
+// • ModuleParameters are set on module parameter fields, which may also be of Go's basic
+// types.
+//
+// • Children are decoded and set on single child fields, in order, with the rest set on the
+// Children slice field.
+//
+// • Assignments and FileAssignments are set on Variables fields, with values that encode
+// to the assigned source.
+//
+// • Exported ModuleName, FunctionName, and Modifier fields are set to the Function's. A
+// Modifier without a field to hold it is applied with Modified.
+//
+// Functions without a registered type decode to themselves, which Encode encodes
+// unchanged, so Functions of modules from other files can be decoded and encoded again. An
+// error is returned if any of a Function's values has no field to hold it.
+func (dec Decoder) Decode(fn Function) (interface{}, error) {
+	name := fn.Name
+	if fn.ModuleName != "" {
+		name = fn.ModuleName
+	}
+
+	t, ok := dec.decodeType(name)
+	if !ok {
+		return fn, nil
+	}
+
+	v := reflect.New(t).Elem()
+
+	hasModifier, err := dec.decodeStruct(fn, v)
+	if err != nil {
+		return nil, err
+	}
+
+	if fn.Modifier != "" && !hasModifier {
+		return Modified{Modifier: fn.Modifier, Child: v.Interface()}, nil
+	}
+
+	return v.Interface(), nil
+}
+
+// decodeType returns the struct type registered for a name, and a boolean indicating if
+// one was found.
+func (dec Decoder) decodeType(name string) (reflect.Type, bool) {
+	if t, ok := dec.Types[name]; ok && t != nil {
+		return t, true
+	}
+
+	if t, ok := decodeTypes.Load(name); ok {
+		return t.(reflect.Type), true
+	}
+
+	return nil, false
+}
+
+// decodeStruct decodes a Function into a struct value, returning a boolean indicating if
+// the struct has a field that holds the Function's Modifier.
+func (dec Decoder) decodeStruct(fn Function, v reflect.Value) (bool, error) {
+	t := v.Type()
+	if t.Kind() != reflect.Struct {
+		return false, fmt.Errorf("scad: attempted to decode Function (%s) to non-struct type (%s)", fn.Name, t)
+	}
+
+	if len(fn.Else) > 0 {
+		return false, fmt.Errorf("scad: attempted to decode Function (%s) with Else children to type (%s)", fn.Name, t)
+	}
+
+	parameters, err := functionParameters(fn)
+	if err != nil {
+		return false, err
+	}
+
+	st := cachedStructType(t)
+
+	// single child fields take their children in order, and the slice field the rest
+	var childFields int
+	for _, sf := range st.fields {
+		if sf.isChild && sf.field.IsExported() {
+			childFields++
+		}
+	}
+	children := fn.Children
+
+	decodedParameters := map[string]bool{}
+	decodedModuleParameters := map[string]bool{}
+	parameterErrs := map[string]error{}
+	var hasModifier, hasAssignments, hasFileAssignments bool
+
+	for _, sf := range st.fields {
+		field := sf.field
+
+		// unexported fields, such as AutoFunctionName fields, have nothing to decode
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldV := settableFieldByIndex(v, field.Index)
+		fieldKind := sf.kind
+		if field.Type.Kind() == reflect.Ptr {
+			fieldKind = sf.elemKind
+		}
+
+		switch {
+		case sf.isChild:
+			childFields--
+			if len(children) == 0 {
+				continue
+			}
+
+			if err := dec.setChild(fieldV, children[0]); err != nil {
+				return false, err
+			}
+			children = children[1:]
+		case sf.isModuleParameter:
+			value, ok := fn.ModuleParameters[sf.name]
+			if !ok {
+				continue
+			}
+
+			if err := setParameterValue(fieldV, value); err != nil {
+				return false, err
+			}
+			decodedModuleParameters[sf.name] = true
+
+			// the Function's parameter references the module parameter by name
+			if parameters[sf.name] == sf.name {
+				decodedParameters[sf.name] = true
+			}
+		case fieldKind.isModuleNameGetter:
+			setString(fieldV, fn.ModuleName)
+		case fieldKind.isFunctionNameGetter:
+			setString(fieldV, fn.Name)
+		case fieldKind.isModifierGetter:
+			hasModifier = setString(fieldV, string(fn.Modifier))
+		case fieldKind.isVariablesGetter:
+			assignments := fn.Assignments
+			if sf.opts.has("file") {
+				assignments = fn.FileAssignments
+				hasFileAssignments = true
+			} else {
+				hasAssignments = true
+			}
+
+			if len(assignments) > 0 {
+				fieldV.Set(reflect.ValueOf(assignmentsVariables(assignments)).Convert(fieldV.Type()))
+			}
+		case fieldKind.isSlice && !fieldKind.isParameterValueGetter:
+			count := len(children) - childFields
+			if count < 0 {
+				count = 0
+			}
+
+			if count == 0 {
+				continue
+			}
+
+			if err := dec.setChildren(fieldV, children[:count]); err != nil {
+				return false, err
+			}
+			children = children[count:]
+		default:
+			value, ok := parameters[sf.name]
+			if !ok || decodedParameters[sf.name] {
+				continue
+			}
+
+			// another field of the same name may accept the value
+			if err := setParameterValue(fieldV, value); err != nil {
+				parameterErrs[sf.name] = err
+				continue
+			}
+			decodedParameters[sf.name] = true
+		}
+	}
+
+	for _, name := range sortedKeys(stringSet(parameters)) {
+		if decodedParameters[name] {
+			continue
+		}
+
+		if err, ok := parameterErrs[name]; ok {
+			return false, fmt.Errorf("scad: attempted to decode Function (%s) with parameter %s to type (%s): %w", fn.Name, name, t, err)
+		}
+
+		return false, fmt.Errorf("scad: attempted to decode Function (%s) with parameter %s to type (%s) without a field for it", fn.Name, name, t)
+	}
+
+	for _, name := range sortedKeys(stringSet(fn.ModuleParameters)) {
+		if !decodedModuleParameters[name] {
+			return false, fmt.Errorf("scad: attempted to decode Function (%s) with module parameter %s to type (%s) without a field for it", fn.Name, name, t)
+		}
+	}
+
+	if len(children) > 0 {
+		return false, fmt.Errorf("scad: attempted to decode Function (%s) with %d children to type (%s) without fields for them", fn.Name, len(fn.Children), t)
+	}
+
+	if (len(fn.Assignments) > 0 && !hasAssignments) || (len(fn.FileAssignments) > 0 && !hasFileAssignments) {
+		return false, fmt.Errorf("scad: attempted to decode Function (%s) with assignments to type (%s) without a Variables field for them", fn.Name, t)
+	}
+
+	return hasModifier, nil
+}
+
+// functionParameters returns a Function's Parameters, along with its Arguments named by
+// the positional parameter names of OpenSCAD's builtin modules.
+func functionParameters(fn Function) (map[string]string, error) {
+	parameters := make(map[string]string, len(fn.Parameters)+len(fn.Arguments))
+
+	names := positionalParameterNames[fn.Name]
+	for i, argument := range fn.Arguments {
+		if i >= len(names) {
+			return nil, fmt.Errorf("scad: attempted to decode Function (%s) with unnamed argument %d: %s", fn.Name, i, argument)
+		}
+
+		parameters[names[i]] = argument
+	}
+
+	for name, value := range fn.Parameters {
+		parameters[name] = value
+	}
+
+	return parameters, nil
+}
+
+// stringSet returns the set of a map's keys.
+func stringSet(m map[string]string) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for key := range m {
+		set[key] = true
+	}
+
+	return set
+}
+
+// settableFieldByIndex returns the field of v at index, which may be within embedded
+// structs, allocating any nil embedded struct pointers.
+func settableFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(fieldIndex)
+	}
+
+	return v
+}
+
+// setString sets a field of a string kind, returning a boolean indicating if it was set.
+func setString(fieldV reflect.Value, value string) bool {
+	if fieldV.Kind() != reflect.String {
+		return false
+	}
+
+	fieldV.SetString(value)
+
+	return true
+}
+
+// setParameterValue sets a field from the string form of a parameter value. Pointer fields,
+// such as value.Float, are set to a new value. ParameterValueSetter types set themselves,
+// and values of Go's basic kinds are parsed.
+func setParameterValue(fieldV reflect.Value, value string) error {
+	if fieldV.Kind() == reflect.Ptr {
+		ptrV := reflect.New(fieldV.Type().Elem())
+		if err := setParameterValue(ptrV.Elem(), value); err != nil {
+			return err
+		}
+
+		fieldV.Set(ptrV.Convert(fieldV.Type()))
+
+		return nil
+	}
+
+	if setter, ok := fieldV.Addr().Interface().(ParameterValueSetter); ok {
+		return setter.SetParameterValue(value)
+	}
+
+	switch fieldV.Kind() {
+	case reflect.String:
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return fmt.Errorf("scad: unable to decode parameter value to type (%s): %s", fieldV.Type(), value)
+		}
+
+		fieldV.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("scad: unable to decode parameter value to type (%s): %s", fieldV.Type(), value)
+		}
+
+		fieldV.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, fieldV.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("scad: unable to decode parameter value to type (%s): %s", fieldV.Type(), value)
+		}
+
+		fieldV.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, fieldV.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("scad: unable to decode parameter value to type (%s): %s", fieldV.Type(), value)
+		}
+
+		fieldV.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fieldV.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("scad: unable to decode parameter value to type (%s): %s", fieldV.Type(), value)
+		}
+
+		fieldV.SetFloat(f)
+	default:
+		return fmt.Errorf("scad: unable to decode parameter value to type (%s)", fieldV.Type())
+	}
+
+	return nil
+}
+
+// setChild decodes a child Function and sets it on a single child field.
+func (dec Decoder) setChild(fieldV reflect.Value, child Function) error {
+	decoded, err := dec.decodeChild(child, fieldV.Type())
+	if err != nil {
+		return err
+	}
+
+	fieldV.Set(decoded)
+
+	return nil
+}
+
+// setChildren decodes children and sets them on a slice field.
+func (dec Decoder) setChildren(fieldV reflect.Value, children []Function) error {
+	sliceV := reflect.MakeSlice(fieldV.Type(), len(children), len(children))
+
+	for i, child := range children {
+		decoded, err := dec.decodeChild(child, fieldV.Type().Elem())
+		if err != nil {
+			return err
+		}
+
+		sliceV.Index(i).Set(decoded)
+	}
+
+	fieldV.Set(sliceV)
+
+	return nil
+}
+
+// decodeChild decodes a child Function to a value assignable to type t. Functions are kept
+// as they are if t holds a Function, such as a []Function Children field.
+func (dec Decoder) decodeChild(child Function, t reflect.Type) (reflect.Value, error) {
+	if t == functionType {
+		return reflect.ValueOf(child), nil
+	}
+
+	decoded, err := dec.Decode(child)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	decodedV := reflect.ValueOf(decoded)
+	if !decodedV.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("scad: attempted to decode child Function (%s) of type (%s) to type (%s)", child.Name, decodedV.Type(), t)
+	}
+
+	return decodedV, nil
+}
+
+// sourceValue is the OpenSCAD source of a decoded assignment's value, which encodes to
+// itself.
+type sourceValue string
+
+// GetParameterValue returns the source, which is always set.
+func (value sourceValue) GetParameterValue() (string, bool) {
+	return string(value), true
+}
+
+// assignmentsVariables returns the Variables for decoded Assignments.
+func assignmentsVariables(assignments []Assignment) Variables {
+	variables := make(Variables, len(assignments))
+	for i, assignment := range assignments {
+		variables[i] = Variable{Name: assignment.Name, Value: sourceValue(assignment.Value)}
+	}
+
+	return variables
+}
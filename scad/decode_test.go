@@ -0,0 +1,297 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSetter is a ParameterValueSetter that rejects vectors.
+type testSetter struct {
+	value string
+	set   bool
+}
+
+func (s testSetter) GetParameterValue() (string, bool) {
+	return s.value, s.set
+}
+
+func (s *testSetter) SetParameterValue(value string) error {
+	if strings.HasPrefix(value, "[") {
+		return errors.New("vector")
+	}
+
+	s.value, s.set = value, true
+
+	return nil
+}
+
+// testVectorSetter is a ParameterValueSetter that only accepts vectors.
+type testVectorSetter struct {
+	testSetter
+}
+
+func (s *testVectorSetter) SetParameterValue(value string) error {
+	if !strings.HasPrefix(value, "[") {
+		return errors.New("not a vector")
+	}
+
+	s.value, s.set = value, true
+
+	return nil
+}
+
+type testCube struct {
+	Size       testSetter       `scad:"size"`
+	SizeVector testVectorSetter `scad:"size"`
+	Center     *testSetter
+}
+
+type testGroup struct {
+	union    AutoFunctionName
+	Children []interface{}
+}
+
+type testShape struct {
+	Name     ModuleName `scad:"shape"`
+	Quality  Variables  `scad:",file"`
+	Modifier Modifier
+
+	Width float64 `scad:"width,parameter"`
+	Label string  `scad:"label,parameter"`
+
+	union    AutoFunctionName
+	Body     interface{} `scad:",child"`
+	Children []Function
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	decoder := Decoder{
+		Types: map[string]reflect.Type{
+			"cube":  reflect.TypeOf(testCube{}),
+			"union": reflect.TypeOf(testGroup{}),
+			"shape": reflect.TypeOf(testShape{}),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		input     Function
+		want      interface{}
+		wantError bool
+	}{
+		{
+			name:  "unregistered",
+			input: Function{Name: "polyhedron", Parameters: map[string]string{"convexity": "1"}},
+			want:  Function{Name: "polyhedron", Parameters: map[string]string{"convexity": "1"}},
+		},
+		{
+			name:  "parameters",
+			input: Function{Name: "cube", Parameters: map[string]string{"size": "10", "center": "true"}},
+			want: testCube{
+				Size:   testSetter{value: "10", set: true},
+				Center: &testSetter{value: "true", set: true},
+			},
+		},
+		{
+			name:  "same name parameters",
+			input: Function{Name: "cube", Parameters: map[string]string{"size": "[1, 2, 3]"}},
+			want: testCube{
+				SizeVector: testVectorSetter{testSetter{value: "[1, 2, 3]", set: true}},
+			},
+		},
+		{
+			name:  "positional arguments",
+			input: Function{Name: "cube", Arguments: []string{"10", "true"}},
+			want: testCube{
+				Size:   testSetter{value: "10", set: true},
+				Center: &testSetter{value: "true", set: true},
+			},
+		},
+		{
+			name:      "extra positional argument",
+			input:     Function{Name: "cube", Arguments: []string{"10", "true", "1"}},
+			wantError: true,
+		},
+		{
+			name:      "unknown parameter",
+			input:     Function{Name: "cube", Parameters: map[string]string{"sides": "6"}},
+			wantError: true,
+		},
+		{
+			name: "children",
+			input: Function{
+				Name: "union",
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"1"}},
+					{Name: "polyhedron"},
+				},
+			},
+			want: testGroup{
+				Children: []interface{}{
+					testCube{Size: testSetter{value: "1", set: true}},
+					Function{Name: "polyhedron"},
+				},
+			},
+		},
+		{
+			name: "child error",
+			input: Function{
+				Name:     "union",
+				Children: []Function{{Name: "cube", Parameters: map[string]string{"sides": "6"}}},
+			},
+			wantError: true,
+		},
+		{
+			name:      "Else",
+			input:     Function{Name: "union", Else: []Function{{Name: "sphere"}}},
+			wantError: true,
+		},
+		{
+			name:  "Modifier without field",
+			input: Function{Name: "union", Modifier: "#"},
+			want:  Modified{Modifier: "#", Child: testGroup{}},
+		},
+		{
+			name:      "assignments without field",
+			input:     Function{Name: "union", Assignments: []Assignment{{Name: "wall", Value: "2"}}},
+			wantError: true,
+		},
+		{
+			name: "module",
+			input: Function{
+				ModuleName:       "shape",
+				Name:             "union",
+				ModuleParameters: map[string]string{"width": "2.5", "label": `"peg"`},
+				FileAssignments:  []Assignment{{Name: "$fn", Value: "24"}},
+				Modifier:         "%",
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"width"}},
+					{Name: "sphere"},
+					{Name: "cylinder"},
+				},
+			},
+			want: testShape{
+				Name:     "shape",
+				Quality:  Variables{{Name: "$fn", Value: sourceValue("24")}},
+				Modifier: "%",
+				Width:    2.5,
+				Label:    "peg",
+				Body:     testCube{Size: testSetter{value: "width", set: true}},
+				Children: []Function{{Name: "sphere"}, {Name: "cylinder"}},
+			},
+		},
+		{
+			name: "module parameter wrong type",
+			input: Function{
+				ModuleName:       "shape",
+				Name:             "union",
+				ModuleParameters: map[string]string{"width": "wide"},
+			},
+			wantError: true,
+		},
+		{
+			name: "unknown module parameter",
+			input: Function{
+				ModuleName:       "shape",
+				Name:             "union",
+				ModuleParameters: map[string]string{"depth": "1"},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := decoder.Decode(test.input)
+		gotError := err != nil
+
+		if gotError != test.wantError {
+			t.Errorf("%q Decode() returned error? %v (%s)", test.name, gotError, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q Decode() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDecoder_Decode_roundTrip(t *testing.T) {
+	decoder := Decoder{
+		Types: map[string]reflect.Type{
+			"cube":  reflect.TypeOf(testCube{}),
+			"union": reflect.TypeOf(testGroup{}),
+			"shape": reflect.TypeOf(testShape{}),
+		},
+	}
+
+	input := testShape{
+		Quality:  Variables{{Name: "$fn", Value: 24}},
+		Modifier: "#",
+		Width:    2,
+		Label:    "peg",
+		Body: testGroup{
+			Children: []interface{}{testCube{SizeVector: testVectorSetter{testSetter{value: "[1, 2, 3]", set: true}}}},
+		},
+		Children: []Function{{Name: "sphere", Parameters: map[string]string{"r": "width"}}},
+	}
+
+	want, err := Encode(input)
+	if err != nil {
+		t.Fatalf("Encode() returned error: %s", err)
+	}
+
+	decoded, err := decoder.Decode(want)
+	if err != nil {
+		t.Fatalf("Decode() returned error: %s", err)
+	}
+
+	got, err := Encode(decoded)
+	if err != nil {
+		t.Fatalf("Encode() of decoded returned error: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode(Decode()) got\n%#v, want\n%#v", got, want)
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType("test_cube", reflect.TypeOf(testCube{}))
+
+	got, err := Decode(Function{Name: "test_cube", Parameters: map[string]string{"size": "5"}})
+	if err != nil {
+		t.Fatalf("Decode() returned error: %s", err)
+	}
+
+	want := testCube{Size: testSetter{value: "5", set: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() got\n%#v, want\n%#v", got, want)
+	}
+
+	// registering nil removes the registration
+	RegisterType("test_cube", nil)
+
+	got, err = Decode(Function{Name: "test_cube", Parameters: map[string]string{"size": "5"}})
+	if err != nil {
+		t.Fatalf("Decode() of unregistered returned error: %s", err)
+	}
+
+	if _, ok := got.(Function); !ok {
+		t.Errorf("Decode() of unregistered got %T, want Function", got)
+	}
+}
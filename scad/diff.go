@@ -0,0 +1,412 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	// Added indicates a Function that is only in the new tree.
+	Added ChangeKind = iota + 1
+
+	// Removed indicates a Function that is only in the old tree.
+	Removed
+
+	// Changed indicates a value of a Function that differs between the trees.
+	Changed
+
+	// Diverged indicates a module with different definitions under the same ModuleName.
+	Diverged
+)
+
+// String returns the lowercase name of the ChangeKind, such as "added".
+func (kind ChangeKind) String() string {
+	switch kind {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	case Diverged:
+		return "diverged"
+	}
+
+	return fmt.Sprintf("ChangeKind(%d)", int(kind))
+}
+
+// Change is a single difference between two Function trees, as returned by Diff.
+type Change struct {
+	Kind ChangeKind
+
+	// OldPath is the location of the Function in the old tree, or nil if it isn't in the
+	// old tree.
+	OldPath Path
+
+	// NewPath is the location of the Function in the new tree, or nil if it isn't in the
+	// new tree.
+	NewPath Path
+
+	// Name is the Function's Name, or its ModuleName for Diverged Changes.
+	Name string
+
+	// Value is the name of the changed value of a Changed Change, prefixed by its kind so
+	// that values of different kinds with the same name are compared separately, such as
+	// "param:v" for translate's vector. Arguments and Parameters are prefixed by "param:",
+	// with positional arguments of OpenSCAD's builtin modules named by their parameter name
+	// and other positional arguments by their index. ModuleParameters are prefixed by
+	// "module:", Assignments by "assign:", FileAssignments by "file:", and the Function's
+	// Modifier is named "modifier".
+	Value string
+
+	// Old and New are the values before and after a Changed Change. An unset value is
+	// empty.
+	Old string
+	New string
+}
+
+// String returns a description of the Change, such as
+// "changed Children[1] translate.param:v [0, 0, 5] -> [0, 0, 6]".
+func (change Change) String() string {
+	path := change.OldPath
+	if change.Kind == Added || path == nil {
+		path = change.NewPath
+	}
+
+	changeStrings := []string{change.Kind.String()}
+	if pathString := path.String(); pathString != "" {
+		changeStrings = append(changeStrings, pathString)
+	}
+
+	switch change.Kind {
+	case Changed:
+		changeStrings = append(changeStrings,
+			fmt.Sprintf("%s.%s", change.Name, change.Value),
+			unsetString(change.Old), "->", unsetString(change.New),
+		)
+	case Diverged:
+		changeStrings = append(changeStrings, fmt.Sprintf("module %s", change.Name))
+	default:
+		changeStrings = append(changeStrings, change.Name)
+	}
+
+	return strings.Join(changeStrings, " ")
+}
+
+// unsetString returns the value, or "unset" if it is empty.
+func unsetString(value string) string {
+	if value == "" {
+		return "unset"
+	}
+
+	return value
+}
+
+// positionalParameterNames is the parameter names of the positional arguments of OpenSCAD's
+// builtin modules, so that positional and named values can be compared.
+var positionalParameterNames = map[string][]string{
+	"circle":         {"r"},
+	"color":          {"c", "alpha"},
+	"cube":           {"size", "center"},
+	"cylinder":       {"h", "r1", "r2", "center"},
+	"import":         {"file"},
+	"linear_extrude": {"height", "center", "convexity", "twist", "slices", "scale"},
+	"mirror":         {"v"},
+	"multmatrix":     {"m"},
+	"offset":         {"r"},
+	"polygon":        {"points", "paths", "convexity"},
+	"polyhedron":     {"points", "faces", "convexity"},
+	"resize":         {"newsize", "auto"},
+	"rotate":         {"a", "v"},
+	"scale":          {"v"},
+	"sphere":         {"r"},
+	"square":         {"size", "center"},
+	"text":           {"text", "size", "font"},
+	"translate":      {"v"},
+}
+
+// normalizeValue returns a value with whitespace outside of string literals removed, so that
+// differently formatted values, such as "[0,0,5]" and "[0, 0, 5]", compare equal.
+func normalizeValue(value string) string {
+	var normalized strings.Builder
+	var inString, escaped bool
+
+	for _, r := range value {
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case !inString && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			continue
+		}
+
+		normalized.WriteRune(r)
+	}
+
+	return normalized.String()
+}
+
+// diffValues returns the named values of a Function that are compared by Diff, keyed by
+// their kind and name, as described by Change.Value.
+func (fn Function) diffValues() map[string]string {
+	values := map[string]string{}
+
+	names := positionalParameterNames[fn.Name]
+	for i, argument := range fn.Arguments {
+		name := strconv.Itoa(i)
+		if i < len(names) {
+			name = names[i]
+		}
+
+		values["param:"+name] = argument
+	}
+
+	for name, value := range fn.Parameters {
+		values["param:"+name] = value
+	}
+
+	for name, value := range fn.ModuleParameters {
+		values["module:"+name] = value
+	}
+
+	for _, assignment := range fn.Assignments {
+		values["assign:"+assignment.Name] = assignment.Value
+	}
+
+	for _, assignment := range fn.FileAssignments {
+		values["file:"+assignment.Name] = assignment.Value
+	}
+
+	if fn.Modifier != "" {
+		values["modifier"] = string(fn.Modifier)
+	}
+
+	return values
+}
+
+// diffKey returns the key used to match Functions between trees. Only Functions with the
+// same key are compared, otherwise they are considered removed and added.
+func (fn Function) diffKey() string {
+	return fn.ModuleName + "/" + fn.Name
+}
+
+// matchChildren returns the pairs of indexes of the longest common subsequence of old and
+// new children, by diffKey.
+func matchChildren(oldChildren, newChildren []Function) [][2]int {
+	// lengths[i][j] is the length of the longest common subsequence of oldChildren[i:] and
+	// newChildren[j:]
+	lengths := make([][]int, len(oldChildren)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(newChildren)+1)
+	}
+
+	for i := len(oldChildren) - 1; i >= 0; i-- {
+		for j := len(newChildren) - 1; j >= 0; j-- {
+			switch {
+			case oldChildren[i].diffKey() == newChildren[j].diffKey():
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var matches [][2]int
+	for i, j := 0, 0; i < len(oldChildren) && j < len(newChildren); {
+		switch {
+		case oldChildren[i].diffKey() == newChildren[j].diffKey():
+			matches = append(matches, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return matches
+}
+
+// diffChildren returns the Changes between two slices of children, which are the Children or
+// Else children of Functions at oldPath and newPath.
+func diffChildren(oldChildren, newChildren []Function, oldPath, newPath Path, isElse bool) []Change {
+	var changes []Change
+
+	removed := func(i int) {
+		changes = append(changes, Change{
+			Kind:    Removed,
+			OldPath: oldPath.child(PathStep{Else: isElse, Index: i}),
+			Name:    oldChildren[i].Name,
+		})
+	}
+
+	added := func(j int) {
+		changes = append(changes, Change{
+			Kind:    Added,
+			NewPath: newPath.child(PathStep{Else: isElse, Index: j}),
+			Name:    newChildren[j].Name,
+		})
+	}
+
+	var i, j int
+	for _, match := range matchChildren(oldChildren, newChildren) {
+		for ; i < match[0]; i++ {
+			removed(i)
+		}
+
+		for ; j < match[1]; j++ {
+			added(j)
+		}
+
+		changes = append(changes, diffFunctions(
+			oldChildren[i], newChildren[j],
+			oldPath.child(PathStep{Else: isElse, Index: i}),
+			newPath.child(PathStep{Else: isElse, Index: j}),
+		)...)
+		i++
+		j++
+	}
+
+	for ; i < len(oldChildren); i++ {
+		removed(i)
+	}
+
+	for ; j < len(newChildren); j++ {
+		added(j)
+	}
+
+	return changes
+}
+
+// diffFunctions returns the Changes between two matched Functions and their descendents.
+func diffFunctions(oldFn, newFn Function, oldPath, newPath Path) []Change {
+	if reflect.DeepEqual(oldFn, newFn) {
+		return nil
+	}
+
+	var changes []Change
+
+	oldValues := oldFn.diffValues()
+	newValues := newFn.diffValues()
+
+	nameSet := map[string]bool{}
+	for _, values := range []map[string]string{oldValues, newValues} {
+		for name := range values {
+			nameSet[name] = true
+		}
+	}
+	names := sortedKeys(nameSet)
+
+	for _, name := range names {
+		if normalizeValue(oldValues[name]) == normalizeValue(newValues[name]) {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Kind:    Changed,
+			OldPath: oldPath,
+			NewPath: newPath,
+			Name:    newFn.Name,
+			Value:   name,
+			Old:     oldValues[name],
+			New:     newValues[name],
+		})
+	}
+
+	changes = append(changes, diffChildren(oldFn.Children, newFn.Children, oldPath, newPath, false)...)
+	changes = append(changes, diffChildren(oldFn.Else, newFn.Else, oldPath, newPath, true)...)
+
+	return changes
+}
+
+// Diff returns the Changes between an old and new Function tree. Children are matched by
+// their Name and ModuleName, preserving order, and unmatched children are reported as
+// Removed or Added. Differing values of matched Functions are reported as Changed, and
+// compared ignoring whitespace. Modules with different definitions under the same
+// ModuleName, whether between or within the trees, are reported as Diverged, after the
+// other Changes.
+func Diff(oldFn, newFn Function) []Change {
+	changes := diffFunctions(oldFn, newFn, Path{}, Path{})
+
+	// the first definition and its path of each module in each tree
+	type moduleLocation struct {
+		definition Function
+		path       Path
+	}
+	oldModules := map[string]moduleLocation{}
+	newModules := map[string]moduleLocation{}
+	var diverged []string
+	divergedNames := map[string]bool{}
+
+	for _, tree := range []struct {
+		fn      Function
+		modules map[string]moduleLocation
+	}{
+		{fn: oldFn, modules: oldModules},
+		{fn: newFn, modules: newModules},
+	} {
+		modules := tree.modules
+
+		Inspect(tree.fn, func(c Cursor) bool {
+			if c.Function.ModuleName == "" {
+				return true
+			}
+
+			name := c.Function.ModuleName
+			definition := c.Function.moduleDefinition()
+			if _, ok := modules[name]; !ok {
+				modules[name] = moduleLocation{definition: definition, path: c.Path}
+			}
+
+			for _, seen := range []map[string]moduleLocation{oldModules, newModules} {
+				if location, ok := seen[name]; ok && !divergedNames[name] && !reflect.DeepEqual(location.definition, definition) {
+					divergedNames[name] = true
+					diverged = append(diverged, name)
+				}
+			}
+
+			return true
+		})
+	}
+
+	for _, name := range diverged {
+		change := Change{Kind: Diverged, Name: name}
+
+		if location, ok := oldModules[name]; ok {
+			change.OldPath = location.path
+		}
+
+		if location, ok := newModules[name]; ok {
+			change.NewPath = location.path
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
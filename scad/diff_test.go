@@ -0,0 +1,227 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	holder := Function{
+		ModuleName: "holder",
+		Name:       "translate",
+		Arguments:  []string{"[0, 0, 5]"},
+		Children:   []Function{{Name: "cube", Arguments: []string{"10"}}},
+	}
+
+	tests := []struct {
+		name     string
+		inputOld Function
+		inputNew Function
+		want     []Change
+	}{
+		{
+			name:     "equal",
+			inputOld: holder,
+			inputNew: holder,
+		},
+		{
+			name: "formatting ignored",
+			inputOld: Function{
+				Name:      "translate",
+				Arguments: []string{"[0,0,5]"},
+			},
+			inputNew: Function{
+				Name:       "translate",
+				Parameters: map[string]string{"v": "[0, 0, 5]"},
+			},
+		},
+		{
+			name: "changed values",
+			inputOld: Function{
+				Name:      "translate",
+				Arguments: []string{"[0,0,5]"},
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"10"}, Parameters: map[string]string{"center": "true"}},
+				},
+			},
+			inputNew: Function{
+				Name:      "translate",
+				Arguments: []string{"[0,0,6]"},
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"10"}, Modifier: Highlight},
+				},
+			},
+			want: []Change{
+				{Kind: Changed, OldPath: Path{}, NewPath: Path{}, Name: "translate", Value: "param:v", Old: "[0,0,5]", New: "[0,0,6]"},
+				{Kind: Changed, OldPath: Path{{Index: 0}}, NewPath: Path{{Index: 0}}, Name: "cube", Value: "modifier", New: "#"},
+				{Kind: Changed, OldPath: Path{{Index: 0}}, NewPath: Path{{Index: 0}}, Name: "cube", Value: "param:center", Old: "true"},
+			},
+		},
+		{
+			name: "added and removed",
+			inputOld: Function{
+				Name: "union",
+				Children: []Function{
+					{Name: "cube"},
+					{Name: "sphere"},
+					{Name: "cylinder"},
+				},
+			},
+			inputNew: Function{
+				Name: "union",
+				Children: []Function{
+					{Name: "square"},
+					{Name: "cube"},
+					{Name: "cylinder"},
+				},
+				Else: []Function{
+					{Name: "circle"},
+				},
+			},
+			want: []Change{
+				{Kind: Added, NewPath: Path{{Index: 0}}, Name: "square"},
+				{Kind: Removed, OldPath: Path{{Index: 1}}, Name: "sphere"},
+				{Kind: Added, NewPath: Path{{Else: true, Index: 0}}, Name: "circle"},
+			},
+		},
+		{
+			name: "diverged module",
+			inputOld: Function{
+				Children: []Function{holder},
+			},
+			inputNew: Function{
+				Children: []Function{
+					{Name: "sphere"},
+					{
+						ModuleName: "holder",
+						Name:       "translate",
+						Arguments:  []string{"[0, 0, 5]"},
+						Children:   []Function{{Name: "cube", Arguments: []string{"12"}}},
+					},
+				},
+			},
+			want: []Change{
+				{Kind: Added, NewPath: Path{{Index: 0}}, Name: "sphere"},
+				{Kind: Changed, OldPath: Path{{Index: 0}, {Index: 0}}, NewPath: Path{{Index: 1}, {Index: 0}}, Name: "cube", Value: "param:size", Old: "10", New: "12"},
+				{Kind: Diverged, OldPath: Path{{Index: 0}}, NewPath: Path{{Index: 1}}, Name: "holder"},
+			},
+		},
+		{
+			name: "module call values don't diverge",
+			inputOld: Function{
+				ModuleName:       "holder",
+				ModuleParameters: map[string]string{"size": "1"},
+			},
+			inputNew: Function{
+				ModuleName:       "holder",
+				ModuleParameters: map[string]string{"size": "2"},
+			},
+			want: []Change{
+				{Kind: Changed, OldPath: Path{}, NewPath: Path{}, Value: "module:size", Old: "1", New: "2"},
+			},
+		},
+		{
+			name: "same names of different kinds",
+			inputOld: Function{
+				Name:        "translate",
+				Parameters:  map[string]string{"v": "[0, 0, 1]", "modifier": "1"},
+				Assignments: []Assignment{{Name: "v", Value: "[0, 0, 2]"}},
+			},
+			inputNew: Function{
+				Name:            "translate",
+				Parameters:      map[string]string{"v": "[0, 0, 1]"},
+				Assignments:     []Assignment{{Name: "v", Value: "[0, 0, 3]"}},
+				FileAssignments: []Assignment{{Name: "v", Value: "[0, 0, 2]"}},
+				Modifier:        Highlight,
+			},
+			want: []Change{
+				{Kind: Changed, OldPath: Path{}, NewPath: Path{}, Name: "translate", Value: "assign:v", Old: "[0, 0, 2]", New: "[0, 0, 3]"},
+				{Kind: Changed, OldPath: Path{}, NewPath: Path{}, Name: "translate", Value: "file:v", New: "[0, 0, 2]"},
+				{Kind: Changed, OldPath: Path{}, NewPath: Path{}, Name: "translate", Value: "modifier", New: "#"},
+				{Kind: Changed, OldPath: Path{}, NewPath: Path{}, Name: "translate", Value: "param:modifier", Old: "1"},
+			},
+		},
+		{
+			name: "diverged within tree",
+			inputOld: Function{
+				Children: []Function{
+					holder,
+					{ModuleName: "holder", Name: "cube"},
+				},
+			},
+			inputNew: Function{
+				Children: []Function{
+					holder,
+					{ModuleName: "holder", Name: "cube"},
+				},
+			},
+			want: []Change{
+				{Kind: Diverged, OldPath: Path{{Index: 0}}, NewPath: Path{{Index: 0}}, Name: "holder"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := Diff(test.inputOld, test.inputNew)
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q Diff() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestChange_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Change
+		want  string
+	}{
+		{
+			name:  "changed",
+			input: Change{Kind: Changed, OldPath: Path{{Index: 1}}, NewPath: Path{{Index: 2}}, Name: "translate", Value: "param:v", Old: "[0,0,5]", New: "[0,0,6]"},
+			want:  "changed Children[1] translate.param:v [0,0,5] -> [0,0,6]",
+		},
+		{
+			name:  "changed unset",
+			input: Change{Kind: Changed, OldPath: Path{}, NewPath: Path{}, Name: "cube", Value: "param:center", Old: "true"},
+			want:  "changed cube.param:center true -> unset",
+		},
+		{
+			name:  "added",
+			input: Change{Kind: Added, NewPath: Path{{Else: true, Index: 0}}, Name: "sphere"},
+			want:  "added Else[0] sphere",
+		},
+		{
+			name:  "removed",
+			input: Change{Kind: Removed, OldPath: Path{{Index: 0}, {Index: 3}}, Name: "cube"},
+			want:  "removed Children[0].Children[3] cube",
+		},
+		{
+			name:  "diverged new only",
+			input: Change{Kind: Diverged, NewPath: Path{{Index: 1}}, Name: "holder"},
+			want:  "diverged Children[1] module holder",
+		},
+	}
+
+	for _, test := range tests {
+		got := test.input.String()
+
+		if got != test.want {
+			t.Errorf("%q String() got\n%q, want\n%q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// emitter writes lines of OpenSCAD content to a buffer, indenting each line by the current
+// depth, so that nested content is written once instead of being re-indented at each level.
+type emitter struct {
+	buf   strings.Builder
+	depth int
+
+	// format is the style of the content.
+	format Format
+}
+
+// startLine writes the indentation of a new line.
+func (e *emitter) startLine() {
+	indent := e.format.indent()
+	for i := 0; i < e.depth; i++ {
+		e.buf.WriteString(indent)
+	}
+}
+
+// line writes an indented line.
+func (e *emitter) line(s string) {
+	e.startLine()
+	e.buf.WriteString(s)
+	e.buf.WriteByte('\n')
+}
+
+// lines writes indented lines, in order.
+func (e *emitter) lines(ss []string) {
+	for _, s := range ss {
+		e.line(s)
+	}
+}
+
+// assignments writes the statements for a slice of Assignments, in order.
+func (e *emitter) assignments(assignments []Assignment) {
+	for _, assignment := range assignments {
+		e.line(fmt.Sprintf("%s = %s;", assignment.Name, e.format.value(assignment.Value)))
+	}
+}
+
+// header writes the Format's Header as comment lines.
+func (e *emitter) header() {
+	if e.format.Header == "" {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(e.format.Header, "\n"), "\n") {
+		e.line(strings.TrimRight("// "+line, " "))
+	}
+}
+
+// callArguments returns the given arguments followed by the given parameters as key=value
+// pairs, in the given order, with the numbers of their values formatted.
+func (e *emitter) callArguments(arguments []string, parameters map[string]string, order []string) []string {
+	callArguments := make([]string, 0, len(arguments)+len(parameters))
+
+	for _, argument := range arguments {
+		callArguments = append(callArguments, e.format.value(argument))
+	}
+
+	for _, key := range orderedKeys(parameters, order) {
+		callArguments = append(callArguments, key+"="+e.format.value(parameters[key]))
+	}
+
+	return callArguments
+}
+
+// call writes a call, such as "translate(v=[0, 0, 1])", without ending its line. If the
+// call is longer than the Format's LineLength, each argument is written on its own line, and
+// the elements of vector arguments that are still too long are written on as few lines as
+// fit.
+func (e *emitter) call(name string, arguments []string) {
+	e.startLine()
+	e.buf.WriteString(name)
+	e.buf.WriteByte('(')
+
+	joined := strings.Join(arguments, ", ")
+	if e.format.LineLength <= 0 || len(arguments) == 0 || e.lineLength(len(name)+len(joined)+2) <= e.format.LineLength {
+		e.buf.WriteString(joined)
+		e.buf.WriteByte(')')
+
+		return
+	}
+	e.buf.WriteByte('\n')
+
+	e.depth++
+	for i, argument := range arguments {
+		separator := ","
+		if i == len(arguments)-1 {
+			separator = ""
+		}
+
+		e.wrappedArgument(argument, separator)
+	}
+	e.depth--
+
+	e.startLine()
+	e.buf.WriteByte(')')
+}
+
+// wrappedArgument writes an argument of a wrapped call on its own line, followed by the
+// separator. A vector argument that is too long for the line has its elements written on as
+// few lines as fit, indented one level further.
+func (e *emitter) wrappedArgument(argument string, separator string) {
+	key, value := "", argument
+	if i := strings.Index(argument, "=["); i >= 0 && !strings.ContainsAny(argument[:i], "\"([") {
+		key, value = argument[:i+1], argument[i+1:]
+	}
+
+	elements, isVector := vectorElements(value)
+	if !isVector || len(elements) == 0 || e.lineLength(len(argument)+len(separator)) <= e.format.LineLength {
+		e.line(argument + separator)
+
+		return
+	}
+
+	e.line(key + "[")
+
+	e.depth++
+	var lineElements []string
+	lineLength := e.lineLength(0)
+
+	for i, element := range elements {
+		// each element is followed by a comma, except the last
+		var comma int
+		if i < len(elements)-1 {
+			comma = 1
+		}
+
+		if len(lineElements) > 0 && lineLength+len(", ")+len(element)+comma > e.format.LineLength {
+			e.line(strings.Join(lineElements, ", ") + ",")
+
+			lineElements = nil
+			lineLength = e.lineLength(0)
+		}
+
+		if len(lineElements) > 0 {
+			lineLength += len(", ")
+		}
+		lineElements = append(lineElements, element)
+		lineLength += len(element)
+
+		if i == len(elements)-1 {
+			e.line(strings.Join(lineElements, ", "))
+		}
+	}
+	e.depth--
+
+	e.line("]" + separator)
+}
+
+// lineLength returns the length of a line at the current depth with n characters after its
+// indentation.
+func (e *emitter) lineLength(n int) int {
+	return e.depth*len(e.format.indent()) + n
+}
+
+// orderedKeys returns the keys of parameters in the given order, followed by any keys that
+// aren't in it, ordered by name.
+func orderedKeys(parameters map[string]string, order []string) []string {
+	keys := make([]string, 0, len(parameters))
+	ordered := make(map[string]bool, len(order))
+
+	for _, key := range order {
+		if _, ok := parameters[key]; ok && !ordered[key] {
+			keys = append(keys, key)
+			ordered[key] = true
+		}
+	}
+
+	var remaining []string
+	for key := range parameters {
+		if !ordered[key] {
+			remaining = append(remaining, key)
+		}
+	}
+	sort.Strings(remaining)
+
+	return append(keys, remaining...)
+}
+
+// emitCall writes the content for a Function, which will either be calling the module by
+// name (if ModuleName is set) or the Function's call contents.
+func (fn Function) emitCall(e *emitter) {
+	if fn.ModuleName == "" {
+		fn.emitFunctionCall(e)
+
+		return
+	}
+
+	e.call(string(fn.Modifier)+fn.ModuleName, e.callArguments(nil, fn.ModuleParameters, fn.ParameterOrder))
+	e.buf.WriteString(";\n")
+}
+
+// emitGroup writes the content of a group Function, which is its Assignments and its
+// children content, at the current depth.
+func (fn Function) emitGroup(e *emitter) {
+	e.assignments(fn.Assignments)
+
+	for _, child := range fn.Children {
+		// the group's modifier applies to each child, stacked with the child's own
+		child.Modifier = fn.Modifier + child.Modifier
+
+		child.emitCall(e)
+	}
+}
+
+// emitFunctionCall writes the content of a Function, including its children content.
+func (fn Function) emitFunctionCall(e *emitter) {
+	if fn.isGroup() {
+		fn.emitGroup(e)
+
+		return
+	}
+
+	e.call(string(fn.Modifier)+fn.Name, e.callArguments(fn.Arguments, fn.Parameters, fn.ParameterOrder))
+
+	if len(fn.Children) == 0 && len(fn.Assignments) == 0 && len(fn.Else) == 0 {
+		e.buf.WriteString(";\n")
+
+		return
+	}
+	e.buf.WriteString(" {\n")
+
+	e.depth++
+	e.assignments(fn.Assignments)
+	for _, child := range fn.Children {
+		child.emitCall(e)
+	}
+	e.depth--
+
+	if len(fn.Else) > 0 {
+		e.line("} else {")
+
+		e.depth++
+		for _, child := range fn.Else {
+			child.emitCall(e)
+		}
+		e.depth--
+	}
+
+	e.line("}")
+}
+
+// emitModuleDefinition writes the definition of a module, surrounding the function's
+// content by the module() { } syntax. ModuleParameters are declared with their values as
+// defaults.
+func (fn Function) emitModuleDefinition(e *emitter) {
+	e.call("module "+fn.ModuleName, e.callArguments(nil, fn.ModuleParameters, fn.ParameterOrder))
+	e.buf.WriteString(" {\n")
+	e.depth++
+
+	// module level assignments belong to the module body, not the block of the module's function,
+	// and the modifier belongs to calls of the module
+	bodyFn := fn
+	bodyFn.Modifier = ""
+	if !fn.isGroup() {
+		e.assignments(fn.Assignments)
+		bodyFn.Assignments = nil
+	}
+	bodyFn.emitFunctionCall(e)
+
+	e.depth--
+	e.line("}")
+}
+
+// emitFile writes all content for the Function that is needed when writing it to a file.
+// This will include the "use" directives, Customizer variables, and file assignments at the
+// top, the module content, and calling the module at the end (so any module file can be
+// opened in OpenSCAD and viewed properly on its own).
+func (fn Function) emitFile(e *emitter) error {
+	e.header()
+
+	chUseStrings, err := fn.childUseStrings()
+	if err != nil {
+		return err
+	}
+	e.lines(chUseStrings)
+
+	fAssignments, err := fileAssignments(fn)
+	if err != nil {
+		return err
+	}
+	fn.emitVariables(e, fAssignments)
+
+	if fn.ModuleName == "" {
+		fn.emitFunctionCall(e)
+	} else {
+		fn.emitModuleDefinition(e)
+
+		// add module call at the end so any module can be opened directly in OpenSCAD
+		if !e.format.OmitSelfCall {
+			e.line(fn.moduleSelfCallString())
+		}
+	}
+
+	return nil
+}
+
+// emitFlat writes all content for the Function as a single self-contained file. Instead of
+// "use" directives, the definition of every module in the Function's tree is included,
+// sorted by name, followed by the Function's own content. The file assignments of every
+// module are made at the top of the file.
+func (fn Function) emitFlat(e *emitter) error {
+	e.header()
+
+	modules, err := fn.allModules()
+	if err != nil {
+		return err
+	}
+
+	assignmentFns := modules
+	if fn.ModuleName == "" {
+		assignmentFns = append([]Function{fn}, modules...)
+	}
+
+	fAssignments, err := fileAssignments(assignmentFns...)
+	if err != nil {
+		return err
+	}
+	fn.emitVariables(e, fAssignments)
+
+	for _, module := range modules {
+		module.emitModuleDefinition(e)
+	}
+
+	if fn.ModuleName == "" {
+		fn.emitFunctionCall(e)
+	} else {
+		e.line(fn.moduleSelfCallString())
+	}
+
+	return nil
+}
+
+// emitVariables writes the top-level variable assignments for a file containing the
+// Function, which are its Customizer variables (if it is a module) and the given file
+// assignments.
+func (fn Function) emitVariables(e *emitter, fAssignments []Assignment) {
+	var cStrings []string
+	if fn.ModuleName != "" {
+		cStrings = fn.customizerAssignmentStrings(e.format)
+	}
+	e.lines(cStrings)
+
+	// hide file assignments from the Customizer, which would otherwise show them with
+	// the customizable parameters
+	if len(cStrings) > 0 && len(fAssignments) > 0 {
+		e.line("/* [Hidden] */")
+	}
+
+	e.assignments(fAssignments)
+}
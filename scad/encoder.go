@@ -0,0 +1,287 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ParameterEncoderFunc returns the string form of a value to be used as a parameter, and a
+// boolean indicating if the value is set, like a ParameterValueGetter's GetParameterValue
+// method. It is passed a value of the type it's registered for.
+type ParameterEncoderFunc func(v interface{}) (string, bool, error)
+
+// NodeEncoderFunc returns the value to encode in place of a value of the type it's
+// registered for, like a SCADEncoder's EncodeSCAD method.
+type NodeEncoderFunc func(v interface{}) (interface{}, error)
+
+var (
+	// parameterEncoders is the global registry of ParameterEncoderFuncs by type.
+	parameterEncoders sync.Map
+
+	// nodeEncoders is the global registry of NodeEncoderFuncs by type.
+	nodeEncoders sync.Map
+)
+
+// RegisterParameterEncoder registers a ParameterEncoderFunc for values of type t, which is
+// used to encode fields and Variables of that type as parameters, instead of the interfaces
+// checked by Encode. This allows types that can't implement ParameterValueGetter, such as
+// those of other packages, to be used as parameters. Registering a nil f removes the
+// registration.
+func RegisterParameterEncoder(t reflect.Type, f ParameterEncoderFunc) {
+	if f == nil {
+		parameterEncoders.Delete(t)
+
+		return
+	}
+
+	parameterEncoders.Store(t, f)
+}
+
+// RegisterNodeEncoder registers a NodeEncoderFunc for values of type t, which is used to
+// encode values of that type before the interfaces checked by Encode. This allows types that
+// can't implement SCADEncoder, such as those of other packages, to be encoded. Registering a
+// nil f removes the registration.
+func RegisterNodeEncoder(t reflect.Type, f NodeEncoderFunc) {
+	if f == nil {
+		nodeEncoders.Delete(t)
+
+		return
+	}
+
+	nodeEncoders.Store(t, f)
+}
+
+// ParameterOrder is the order in which an Encoder has the parameters of encoded Functions
+// written.
+type ParameterOrder int
+
+const (
+	// AlphabeticalOrder has parameters written ordered by name.
+	AlphabeticalOrder ParameterOrder = iota
+
+	// DeclarationOrder has parameters written in the order of the struct fields they are
+	// encoded from, by recording it as each Function's ParameterOrder.
+	DeclarationOrder
+)
+
+// Encoder encodes values to Functions. Its encoders are consulted before those registered
+// globally, so encoders can be used without registering them for the whole program. The zero
+// value encodes the same as Encode.
+type Encoder struct {
+	// ParameterEncoders are the ParameterEncoderFuncs by type, as registered globally by
+	// RegisterParameterEncoder.
+	ParameterEncoders map[reflect.Type]ParameterEncoderFunc
+
+	// NodeEncoders are the NodeEncoderFuncs by type, as registered globally by
+	// RegisterNodeEncoder.
+	NodeEncoders map[reflect.Type]NodeEncoderFunc
+
+	// ParameterOrder is the order in which the parameters of encoded Functions are written.
+	// The default is AlphabeticalOrder.
+	ParameterOrder ParameterOrder
+}
+
+// Encode encodes an interface into a Function, as described by the Encode function.
+func (enc Encoder) Encode(i interface{}) (Function, error) {
+	state := &encodeState{encoder: enc}
+
+	fn, err := state.encode(i, &encodePath{value: i})
+	if err != nil {
+		return Function{}, err
+	}
+
+	if len(state.validationErrs) > 0 {
+		return Function{}, state.validationErrs
+	}
+
+	return fn, nil
+}
+
+// parameterEncoder returns the ParameterEncoderFunc for a type, and a boolean indicating if
+// one was found.
+func (enc Encoder) parameterEncoder(t reflect.Type) (ParameterEncoderFunc, bool) {
+	if f, ok := enc.ParameterEncoders[t]; ok && f != nil {
+		return f, true
+	}
+
+	if f, ok := parameterEncoders.Load(t); ok {
+		return f.(ParameterEncoderFunc), true
+	}
+
+	return nil, false
+}
+
+// hasParameterEncoder returns a boolean indicating if there is a ParameterEncoderFunc for a
+// type, or for the type it points to.
+func (enc Encoder) hasParameterEncoder(t reflect.Type) bool {
+	if _, ok := enc.parameterEncoder(t); ok {
+		return true
+	}
+
+	if t.Kind() == reflect.Ptr {
+		_, ok := enc.parameterEncoder(t.Elem())
+
+		return ok
+	}
+
+	return false
+}
+
+// hasEncoder returns a boolean indicating if there is a ParameterEncoderFunc or
+// NodeEncoderFunc for a type.
+func (enc Encoder) hasEncoder(t reflect.Type) bool {
+	if enc.hasParameterEncoder(t) {
+		return true
+	}
+
+	_, ok := enc.nodeEncoder(t)
+
+	return ok
+}
+
+// nodeEncoder returns the NodeEncoderFunc for a type, and a boolean indicating if one was
+// found.
+func (enc Encoder) nodeEncoder(t reflect.Type) (NodeEncoderFunc, bool) {
+	if f, ok := enc.NodeEncoders[t]; ok && f != nil {
+		return f, true
+	}
+
+	if f, ok := nodeEncoders.Load(t); ok {
+		return f.(NodeEncoderFunc), true
+	}
+
+	return nil, false
+}
+
+// Block is a Function whose Children and Else children are yet to be encoded. SCADEncoder
+// types that have children of their own return a Block from EncodeSCAD, so the children are
+// encoded along with the rest of the value, with the same Encoder.
+type Block struct {
+	// Function is the encoded Function, to which the encoded Children and Else are added.
+	Function Function
+
+	// Arguments are passed to the Function as named arguments, such as "x=1", after any
+	// Arguments it already has. Arguments with unset values are not passed.
+	Arguments Variables
+
+	Children []interface{}
+
+	Else []interface{}
+}
+
+// encodeState is the state of a single encoding.
+type encodeState struct {
+	// encoder is the Encoder whose encoders are consulted.
+	encoder Encoder
+
+	// validationErrs are the errors returned by Validators, with their paths.
+	validationErrs ValidationErrors
+}
+
+// recordParameter records the name of a parameter set on a Function in its ParameterOrder,
+// if the Encoder's ParameterOrder is DeclarationOrder.
+func (state *encodeState) recordParameter(fn *Function, name string) {
+	if state.encoder.ParameterOrder != DeclarationOrder {
+		return
+	}
+
+	fn.ParameterOrder = mergeParameterOrders(fn.ParameterOrder, []string{name})
+}
+
+// mergeParameterOrders returns the names of order followed by those of other that aren't
+// already in it.
+func mergeParameterOrders(order []string, other []string) []string {
+	for _, name := range other {
+		found := false
+		for _, orderName := range order {
+			if orderName == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			order = append(order, name)
+		}
+	}
+
+	return order
+}
+
+// encodeBlock encodes a Block's arguments and children into its Function.
+func (state *encodeState) encodeBlock(block Block, path *encodePath) (Function, error) {
+	fn := block.Function
+
+	assignments, err := variablesAssignments(block.Arguments, state.encoder)
+	if err != nil {
+		return Function{}, err
+	}
+
+	for _, assignment := range assignments {
+		fn.Arguments = append(fn.Arguments, assignment.Name+"="+assignment.Value)
+	}
+
+	children, err := state.encodeChildren(block.Children, "Children", path)
+	if err != nil {
+		return Function{}, err
+	}
+	fn.Children = append(fn.Children, children...)
+
+	elseChildren, err := state.encodeChildren(block.Else, "Else", path)
+	if err != nil {
+		return Function{}, err
+	}
+	fn.Else = append(fn.Else, elseChildren...)
+
+	return fn, nil
+}
+
+// encodeChildren encodes each of the given children to a Function. The name is the name of
+// the children's field, such as "Children", which is added to the path of each child.
+func (state *encodeState) encodeChildren(children []interface{}, name string, path *encodePath) ([]Function, error) {
+	if children == nil {
+		return nil, nil
+	}
+
+	fns := make([]Function, len(children))
+
+	for i, child := range children {
+		fn, err := state.encode(child, &encodePath{parent: path, field: name, index: i, value: child})
+		if err != nil {
+			return nil, err
+		}
+
+		fns[i] = fn
+	}
+
+	return fns, nil
+}
+
+// encodeNode encodes a value with a registered NodeEncoderFunc.
+func (state *encodeState) encodeNode(f NodeEncoderFunc, i interface{}, path *encodePath) (Function, error) {
+	encoded, err := f(i)
+	if err != nil {
+		return Function{}, err
+	}
+
+	if encoded == nil {
+		return Function{}, fmt.Errorf("scad: node encoder for type (%T) returned nil", i)
+	}
+
+	return state.encode(encoded, path)
+}
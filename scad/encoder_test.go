@@ -0,0 +1,370 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testPoint is a type that implements none of the interfaces checked by Encode.
+type testPoint struct {
+	X, Y, Z float64
+}
+
+// testPointParameter encodes a testPoint as a vector.
+func testPointParameter(v interface{}) (string, bool, error) {
+	p := v.(testPoint)
+
+	return fmt.Sprintf("[%v,%v,%v]", p.X, p.Y, p.Z), true, nil
+}
+
+// testMarker is a type that implements none of the interfaces checked by Encode.
+type testMarker float64
+
+// testMarkerNode encodes a testMarker as a sphere.
+func testMarkerNode(v interface{}) (interface{}, error) {
+	size := v.(testMarker)
+	if size <= 0 {
+		return nil, errors.New("marker has no size")
+	}
+
+	return Function{Name: "sphere", Arguments: []string{fmt.Sprint(float64(size))}}, nil
+}
+
+// testTranslate has a testPoint parameter field.
+type testTranslate struct {
+	translate AutoFunctionName
+	V         testPoint
+	Children  []interface{}
+}
+
+// testPointModule has a testPoint module parameter field.
+type testPointModule struct {
+	Name   ModuleName `scad:"marked"`
+	Origin *testPoint `scad:"origin,parameter"`
+	Offset testPoint  `scad:"offset,parameter,omitempty"`
+	union  AutoFunctionName
+}
+
+// TestPosition is an embeddable type with a parameter field.
+type TestPosition struct {
+	V testParameterValueGetter `scad:"v"`
+}
+
+// testPositionParameter encodes a TestPosition as a vector.
+func testPositionParameter(v interface{}) (string, bool, error) {
+	p := v.(TestPosition)
+
+	return fmt.Sprintf("[%s,0,0]", p.V.value), true, nil
+}
+
+// testBlockEncoder returns a Block.
+type testBlockEncoder struct {
+	Arguments Variables     `scad:"-"`
+	Children  []interface{} `scad:"-"`
+	Else      []interface{} `scad:"-"`
+}
+
+func (v testBlockEncoder) EncodeSCAD() (interface{}, error) {
+	return Block{
+		Function:  Function{Name: "if", Arguments: []string{"true"}},
+		Arguments: v.Arguments,
+		Children:  v.Children,
+		Else:      v.Else,
+	}, nil
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	pointType := reflect.TypeOf(testPoint{})
+	markerType := reflect.TypeOf(testMarker(0))
+
+	encoder := Encoder{
+		ParameterEncoders: map[reflect.Type]ParameterEncoderFunc{
+			pointType:                      testPointParameter,
+			reflect.TypeOf(TestPosition{}): testPositionParameter,
+		},
+		NodeEncoders: map[reflect.Type]NodeEncoderFunc{markerType: testMarkerNode},
+	}
+
+	tests := []struct {
+		name         string
+		encoder      Encoder
+		input        interface{}
+		wantFunction Function
+		wantError    bool
+	}{
+		{
+			name:  "unregistered parameter",
+			input: testTranslate{V: testPoint{X: 1}},
+			wantFunction: Function{
+				Name:     "translate",
+				Children: []Function{},
+			},
+		},
+		{
+			name:    "registered parameter",
+			encoder: encoder,
+			input:   testTranslate{V: testPoint{X: 1}, Children: []interface{}{}},
+			wantFunction: Function{
+				Name:       "translate",
+				Parameters: map[string]string{"v": "[1,0,0]"},
+				Children:   []Function{},
+			},
+		},
+		{
+			name:    "registered module parameter",
+			encoder: encoder,
+			input:   testPointModule{Origin: &testPoint{Z: 2}},
+			wantFunction: Function{
+				ModuleName:       "marked",
+				Name:             "union",
+				Parameters:       map[string]string{"origin": "origin"},
+				ModuleParameters: map[string]string{"origin": "[0,0,2]"},
+			},
+		},
+		{
+			name:      "unregistered node",
+			input:     testTranslate{Children: []interface{}{testMarker(1)}},
+			wantError: true,
+		},
+		{
+			name:    "registered node",
+			encoder: encoder,
+			input:   testTranslate{Children: []interface{}{testMarker(1)}},
+			wantFunction: Function{
+				Name:       "translate",
+				Parameters: map[string]string{"v": "[0,0,0]"},
+				Children:   []Function{{Name: "sphere", Arguments: []string{"1"}}},
+			},
+		},
+		{
+			name:      "registered node error",
+			encoder:   encoder,
+			input:     testTranslate{Children: []interface{}{testMarker(0)}},
+			wantError: true,
+		},
+		{
+			name:    "Block",
+			encoder: encoder,
+			input: testBlockEncoder{
+				Children: []interface{}{testMarker(1)},
+				Else:     []interface{}{testMarker(2)},
+			},
+			wantFunction: Function{
+				Name:      "if",
+				Arguments: []string{"true"},
+				Children:  []Function{{Name: "sphere", Arguments: []string{"1"}}},
+				Else:      []Function{{Name: "sphere", Arguments: []string{"2"}}},
+			},
+		},
+		{
+			name:    "Block arguments",
+			encoder: encoder,
+			input: testBlockEncoder{
+				Arguments: Variables{
+					{Name: "p", Value: testPoint{X: 1}},
+					{Name: "unset", Value: testParameterValueGetter{value: "2"}},
+					{Name: "n", Value: 3},
+				},
+			},
+			wantFunction: Function{
+				Name:      "if",
+				Arguments: []string{"true", "p=[1,0,0]", "n=3"},
+			},
+		},
+		{
+			name: "unregistered embedded struct",
+			input: struct {
+				translate AutoFunctionName
+				TestPosition
+			}{
+				TestPosition: TestPosition{V: testParameterValueGetter{value: "1", explicit: true}},
+			},
+			wantFunction: Function{
+				Name:       "translate",
+				Parameters: map[string]string{"v": "1"},
+			},
+		},
+		{
+			name:    "registered embedded struct",
+			encoder: encoder,
+			input: struct {
+				translate AutoFunctionName
+				TestPosition
+			}{
+				TestPosition: TestPosition{V: testParameterValueGetter{value: "1", explicit: true}},
+			},
+			wantFunction: Function{
+				Name:       "translate",
+				Parameters: map[string]string{"testposition": "[1,0,0]"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		gotFunction, err := test.encoder.Encode(test.input)
+		gotError := err != nil
+
+		if gotError != test.wantError {
+			t.Errorf("%q Encode() returned error? %v (%s)", test.name, gotError, err)
+		}
+
+		if !reflect.DeepEqual(gotFunction, test.wantFunction) {
+			t.Errorf("%q Encode() got\n%#v, want\n%#v", test.name, gotFunction, test.wantFunction)
+		}
+	}
+}
+
+func TestEncoder_Encode_errorPath(t *testing.T) {
+	encoder := Encoder{
+		NodeEncoders: map[reflect.Type]NodeEncoderFunc{reflect.TypeOf(testMarker(0)): testMarkerNode},
+	}
+
+	input := testTranslate{
+		Children: []interface{}{
+			testBlockEncoder{
+				Else: []interface{}{testMarker(1), testMarker(0)},
+			},
+		},
+	}
+	wantPath := "testTranslate.Children[0].testBlockEncoder.Else[1].testMarker"
+
+	_, err := encoder.Encode(input)
+
+	var encodeErr *EncodeError
+	if !errors.As(err, &encodeErr) {
+		t.Fatalf("Encode() returned non-EncodeError error: %v", err)
+	}
+
+	if encodeErr.Path != wantPath {
+		t.Errorf("Encode() error path got\n%q, want\n%q", encodeErr.Path, wantPath)
+	}
+}
+
+func TestRegisterParameterEncoder(t *testing.T) {
+	pointType := reflect.TypeOf(testPoint{})
+
+	RegisterParameterEncoder(pointType, testPointParameter)
+	defer RegisterParameterEncoder(pointType, nil)
+
+	input := testTranslate{V: testPoint{Y: 3}, Children: []interface{}{}}
+
+	gotFunction, err := Encode(input)
+	if err != nil {
+		t.Fatalf("Encode() returned error: %s", err)
+	}
+
+	wantFunction := Function{
+		Name:       "translate",
+		Parameters: map[string]string{"v": "[0,3,0]"},
+		Children:   []Function{},
+	}
+
+	if !reflect.DeepEqual(gotFunction, wantFunction) {
+		t.Errorf("Encode() got\n%#v, want\n%#v", gotFunction, wantFunction)
+	}
+
+	// an Encoder's own encoders take precedence
+	encoder := Encoder{
+		ParameterEncoders: map[reflect.Type]ParameterEncoderFunc{
+			pointType: func(v interface{}) (string, bool, error) {
+				return "origin", true, nil
+			},
+		},
+	}
+
+	gotFunction, err = encoder.Encode(input)
+	if err != nil {
+		t.Fatalf("Encoder.Encode() returned error: %s", err)
+	}
+
+	wantFunction.Parameters = map[string]string{"v": "origin"}
+
+	if !reflect.DeepEqual(gotFunction, wantFunction) {
+		t.Errorf("Encoder.Encode() got\n%#v, want\n%#v", gotFunction, wantFunction)
+	}
+}
+
+func TestRegisterNodeEncoder(t *testing.T) {
+	markerType := reflect.TypeOf(testMarker(0))
+
+	RegisterNodeEncoder(markerType, testMarkerNode)
+
+	gotFunction, err := Encode(testMarker(4))
+	if err != nil {
+		t.Fatalf("Encode() returned error: %s", err)
+	}
+
+	wantFunction := Function{Name: "sphere", Arguments: []string{"4"}}
+
+	if !reflect.DeepEqual(gotFunction, wantFunction) {
+		t.Errorf("Encode() got\n%#v, want\n%#v", gotFunction, wantFunction)
+	}
+
+	// registering nil removes the registration
+	RegisterNodeEncoder(markerType, nil)
+
+	if _, err := Encode(testMarker(4)); err == nil {
+		t.Errorf("Encode() of unregistered type returned no error")
+	}
+}
+
+// testOrdered has parameters declared out of alphabetical order.
+type testOrdered struct {
+	Name   ModuleName               `scad:"peg"`
+	Width  float64                  `scad:"width,parameter"`
+	Height testParameterValueGetter `scad:"height"`
+	Depth  testParameterValueGetter `scad:"depth"`
+	cube   AutoFunctionName
+}
+
+func TestEncoder_Encode_parameterOrder(t *testing.T) {
+	input := testOrdered{
+		Width:  2,
+		Height: testParameterValueGetter{value: "10", explicit: true},
+		Depth:  testParameterValueGetter{value: "1", explicit: true},
+	}
+
+	tests := []struct {
+		name    string
+		encoder Encoder
+		want    []string
+	}{
+		{
+			name:    "alphabetical",
+			encoder: Encoder{},
+		},
+		{
+			name:    "declaration",
+			encoder: Encoder{ParameterOrder: DeclarationOrder},
+			want:    []string{"width", "height", "depth"},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.encoder.Encode(input)
+		if err != nil {
+			t.Errorf("%q Encode() returned error: %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got.ParameterOrder, test.want) {
+			t.Errorf("%q Encode() ParameterOrder got\n%#v, want\n%#v", test.name, got.ParameterOrder, test.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EncodeError is an error encountered by Encode, other than a ValidationError.
+type EncodeError struct {
+	// Path is the path to the value that caused the error, starting with the encoded type's
+	// name, such as "Die.Children[0].Dimples.Children[3].Translate".
+	Path string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the error message, prefixed with the Path.
+func (err *EncodeError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Path, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *EncodeError) Unwrap() error {
+	return err.Err
+}
+
+// ValidationError is an error returned by a Validator's ValidateSCAD method during Encode.
+type ValidationError struct {
+	// Path is the path to the invalid value, starting with the encoded type's name, such as
+	// "Die.Children[0].Dimples".
+	Path string
+
+	// Err is the error returned by ValidateSCAD.
+	Err error
+}
+
+// Error returns the error message, prefixed with the Path.
+func (err *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Path, err.Err)
+}
+
+// Unwrap returns the error returned by ValidateSCAD.
+func (err *ValidationError) Unwrap() error {
+	return err.Err
+}
+
+// ValidationErrors is the ValidationErrors of every invalid value found by Encode, in the
+// order they were found.
+type ValidationErrors []*ValidationError
+
+// Error returns the messages of the ValidationErrors, one per line.
+func (errs ValidationErrors) Error() string {
+	errStrings := make([]string, len(errs))
+	for i, err := range errs {
+		errStrings[i] = err.Error()
+	}
+
+	return strings.Join(errStrings, "\n")
+}
+
+// Unwrap returns the ValidationErrors as a slice of errors.
+func (errs ValidationErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+
+	return unwrapped
+}
+
+// Is returns a boolean indicating if any of the ValidationErrors matches target, as reported
+// by errors.Is, which doesn't look through Unwrap's slice of errors before Go 1.20.
+func (errs ValidationErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As sets target to the first of the ValidationErrors that matches it, as reported by
+// errors.As, and returns a boolean indicating if one was found.
+func (errs ValidationErrors) As(target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodePath is the path to a value being encoded, which is only formatted when needed for
+// an error.
+type encodePath struct {
+	// parent is the path to the value's parent, or nil for the first value of the path.
+	parent *encodePath
+
+	// field and index locate the value within its parent's field, unless field is empty.
+	// The index is negative for single child fields.
+	field string
+	index int
+
+	// value is the value itself, named by its type.
+	value interface{}
+}
+
+// String returns the path in the form "Die.Children[0].Dimples".
+func (p *encodePath) String() string {
+	var prefix string
+	if p.parent != nil {
+		prefix = p.parent.String() + "."
+	}
+
+	switch {
+	case p.field != "" && p.index < 0:
+		prefix += p.field + "."
+	case p.field != "":
+		prefix += fmt.Sprintf("%s[%d].", p.field, p.index)
+	}
+
+	return prefix + valuePath(p.value)
+}
+
+// valuePath returns the path element for a value, which is the name of its type, or the
+// type itself for unnamed types. Pointers are dereferenced.
+func valuePath(i interface{}) string {
+	t := reflect.TypeOf(i)
+	if t == nil {
+		return "nil"
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Name() == "" {
+		return t.String()
+	}
+
+	return t.Name()
+}
@@ -0,0 +1,289 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testValidated struct {
+	cube AutoFunctionName //nolint:golint,structcheck,unused
+
+	Size int `scad:"-"`
+}
+
+func (v testValidated) ValidateSCAD() error {
+	if v.Size < 0 {
+		return fmt.Errorf("Size is %d, must not be negative", v.Size)
+	}
+
+	return nil
+}
+
+type testValidatedParent struct {
+	union AutoFunctionName //nolint:golint,structcheck,unused
+
+	Invalid  bool `scad:"-"`
+	Children []interface{}
+}
+
+func (v testValidatedParent) ValidateSCAD() error {
+	if v.Invalid {
+		return errors.New("parent is invalid")
+	}
+
+	return nil
+}
+
+// testValidatedEncoder encodes its children itself.
+type testValidatedEncoder struct {
+	Children []interface{} `scad:"-"`
+}
+
+func (v testValidatedEncoder) EncodeSCAD() (interface{}, error) {
+	fn := Function{Name: "union"}
+
+	for _, child := range v.Children {
+		childFn, err := Encode(child)
+		if err != nil {
+			return nil, err
+		}
+
+		fn.Children = append(fn.Children, childFn)
+	}
+
+	return fn, nil
+}
+
+// testChildrenEncoder encodes its children with EncodeChildren.
+type testChildrenEncoder struct {
+	Children []interface{} `scad:"-"`
+}
+
+func (v testChildrenEncoder) EncodeSCAD() (interface{}, error) {
+	children, err := EncodeChildren("Children", v.Children)
+	if err != nil {
+		return nil, err
+	}
+
+	return Function{Name: "union", Children: children}, nil
+}
+
+// testFailingEncoder returns an error from EncodeSCAD.
+type testFailingEncoder struct{}
+
+func (v testFailingEncoder) EncodeSCAD() (interface{}, error) {
+	return nil, errors.New("failed")
+}
+
+func TestEncode_errorPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		wantPath string
+	}{
+		{
+			name:     "nil",
+			input:    nil,
+			wantPath: "nil",
+		},
+		{
+			name: "root",
+			input: struct {
+				union    AutoFunctionName
+				Children []interface{}
+				More     []interface{}
+			}{},
+			wantPath: "struct { union scad.AutoFunctionName; Children []interface {}; More []interface {} }",
+		},
+		{
+			name: "child",
+			input: testValidatedParent{
+				Children: []interface{}{
+					testValidated{},
+					testValidatedParent{
+						Children: []interface{}{testValidated{}, 1},
+					},
+				},
+			},
+			wantPath: "testValidatedParent.Children[1].testValidatedParent.Children[1].int",
+		},
+		{
+			name: "EncodeSCAD error",
+			input: testValidatedParent{
+				Children: []interface{}{&testFailingEncoder{}},
+			},
+			wantPath: "testValidatedParent.Children[0].testFailingEncoder",
+		},
+		{
+			name: "EncodeChildren error",
+			input: testValidatedParent{
+				Children: []interface{}{
+					testChildrenEncoder{
+						Children: []interface{}{testValidated{}, testFailingEncoder{}},
+					},
+				},
+			},
+			wantPath: "testValidatedParent.Children[0].testChildrenEncoder.Children[1].testFailingEncoder",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Encode(test.input)
+
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) {
+			t.Errorf("%q Encode() returned non-EncodeError error: %v", test.name, err)
+			continue
+		}
+
+		if encodeErr.Path != test.wantPath {
+			t.Errorf("%q Encode() error path got\n%q, want\n%q", test.name, encodeErr.Path, test.wantPath)
+		}
+	}
+}
+
+func TestEncode_validation(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     interface{}
+		want      Function
+		wantPaths []string
+	}{
+		{
+			name: "valid",
+			input: testValidatedParent{
+				Children: []interface{}{testValidated{Size: 1}},
+			},
+			want: Function{
+				Name:     "union",
+				Children: []Function{{Name: "cube"}},
+			},
+		},
+		{
+			name:      "invalid",
+			input:     testValidated{Size: -1},
+			wantPaths: []string{"testValidated"},
+		},
+		{
+			name: "every error",
+			input: &testValidatedParent{
+				Invalid: true,
+				Children: []interface{}{
+					testValidated{Size: -1},
+					testValidated{Size: 1},
+					testValidatedParent{
+						Children: []interface{}{testValidated{Size: -2}},
+					},
+				},
+			},
+			wantPaths: []string{
+				"testValidatedParent",
+				"testValidatedParent.Children[0].testValidated",
+				"testValidatedParent.Children[2].testValidatedParent.Children[0].testValidated",
+			},
+		},
+		{
+			name: "encoded by EncodeSCAD",
+			input: testValidatedParent{
+				Children: []interface{}{
+					testValidatedEncoder{
+						Children: []interface{}{testValidated{Size: -1}},
+					},
+				},
+			},
+			wantPaths: []string{
+				"testValidatedParent.Children[0].testValidatedEncoder.testValidated",
+			},
+		},
+		{
+			name: "encoded by EncodeChildren",
+			input: testChildrenEncoder{
+				Children: []interface{}{testValidated{Size: 1}, testValidated{Size: -1}},
+			},
+			wantPaths: []string{
+				"testChildrenEncoder.Children[1].testValidated",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Encode(test.input)
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q Encode() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+
+		var gotPaths []string
+		var validationErrs ValidationErrors
+		if errors.As(err, &validationErrs) {
+			for _, validationErr := range validationErrs {
+				gotPaths = append(gotPaths, validationErr.Path)
+			}
+		} else if err != nil {
+			t.Errorf("%q Encode() returned non-ValidationErrors error: %s", test.name, err)
+		}
+
+		if !reflect.DeepEqual(gotPaths, test.wantPaths) {
+			t.Errorf("%q Encode() error paths got\n%#v, want\n%#v", test.name, gotPaths, test.wantPaths)
+		}
+	}
+}
+
+func TestValidationErrors_Error(t *testing.T) {
+	errs := ValidationErrors{
+		{Path: "Die", Err: errors.New("too small")},
+		{Path: "Die.Children[0].Dimples", Err: errors.New("too many")},
+	}
+
+	want := "Die: too small\nDie.Children[0].Dimples: too many"
+	if got := errs.Error(); got != want {
+		t.Errorf("Error() got\n%q, want\n%q", got, want)
+	}
+}
+
+func TestValidationErrors_IsAs(t *testing.T) {
+	errTooMany := errors.New("too many")
+
+	var err error = ValidationErrors{
+		{Path: "Die", Err: errors.New("too small")},
+		{Path: "Die.Children[0].Dimples", Err: errTooMany},
+	}
+
+	if !errors.Is(err, errTooMany) {
+		t.Errorf("errors.Is() got false, want true")
+	}
+
+	if errors.Is(err, errors.New("too many")) {
+		t.Errorf("errors.Is() with unrelated error got true, want false")
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("errors.As() got false, want true")
+	}
+
+	if validationErr.Path != "Die" {
+		t.Errorf("errors.As() got Path %q, want %q", validationErr.Path, "Die")
+	}
+
+	wrapped := fmt.Errorf("encoding: %w", err)
+	if !errors.Is(wrapped, errTooMany) {
+		t.Errorf("errors.Is() with wrapped error got false, want true")
+	}
+}
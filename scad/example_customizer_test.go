@@ -0,0 +1,79 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/value"
+)
+
+type Block struct {
+	Name scad.ModuleName `scad:"block"`
+
+	Width  float64 `scad:"width,customize,range=20:100,step=1,group=Dimensions" description:"Width of the block"`
+	Height float64 `scad:"height,customize,range=5:50,group=Dimensions"`
+	Center bool    `scad:"center,customize"`
+}
+
+func (block Block) EncodeSCAD() (interface{}, error) {
+	width := value.Var("width")
+
+	return primitive3d.Cube{
+		SizeXYZ: value.NewFloatXYZExpr(value.Vector(width, width, value.Var("height"))),
+		Center:  value.NewBoolExpr(value.Var("center")),
+	}, nil
+}
+
+func ExampleEncodeParameterSets() {
+	fn, sets, _ := scad.EncodeParameterSets(map[string]interface{}{
+		"small": Block{Width: 20, Height: 10},
+		"large": Block{Width: 80, Height: 40, Center: true},
+	})
+
+	content, _ := scad.FunctionContent(fn)
+	fmt.Println(content)
+
+	setsContent, _ := json.MarshalIndent(sets, "", "  ")
+	fmt.Println(string(setsContent))
+	// Output: center = true;
+	// /* [Dimensions] */
+	// // Width of the block
+	// width = 80; // [20:1:100]
+	// height = 40; // [5:50]
+	// module block(center=true, height=40, width=80) {
+	//   cube(center=center, size=[width, width, height]);
+	// }
+	// block(center=center, height=height, width=width);
+	//
+	// {
+	//   "parameterSets": {
+	//     "large": {
+	//       "center": "true",
+	//       "height": "40",
+	//       "width": "80"
+	//     },
+	//     "small": {
+	//       "center": "false",
+	//       "height": "10",
+	//       "width": "20"
+	//     }
+	//   },
+	//   "fileFormatVersion": "1"
+	// }
+}
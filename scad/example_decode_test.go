@@ -0,0 +1,47 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/parser"
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/transformation"
+	"go.incompletion.ist/go-scad/value"
+)
+
+func ExampleDecode() {
+	src := `translate([0, 0, 5]) cylinder(h=10, r=2, $fn=24);`
+
+	file, _ := parser.Parse("peg.scad", []byte(src))
+
+	decoded, _ := scad.Decode(file.Root.Children[0])
+	translate := decoded.(transformation.Translate)
+	fmt.Println(translate.V.ValueZ())
+
+	// make the peg taller
+	cylinder := translate.Children[0].(primitive3d.Cylinder)
+	cylinder.H = value.NewFloat(20)
+	translate.Children[0] = cylinder
+
+	content, _ := scad.FunctionContent(translate)
+	fmt.Println(content)
+	// Output: 5
+	// translate(v=[0, 0, 5]) {
+	//   cylinder($fn=24, h=20, r=2);
+	// }
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/value"
+)
+
+// Resolution is shared by types that render curved surfaces.
+type Resolution struct {
+	FA value.Float `scad:"$fa"`
+	FS value.Float `scad:"$fs"`
+	FN value.Int   `scad:"$fn"`
+}
+
+// Bead is a sphere with a hole through it.
+type Bead struct {
+	difference scad.AutoFunctionName //nolint:golint,structcheck,unused
+
+	Body interface{} `scad:",child,required"`
+	Hole interface{} `scad:",child"`
+}
+
+// Hole is a cylinder with its own Resolution.
+type Hole struct {
+	cylinder scad.AutoFunctionName //nolint:golint,structcheck,unused
+
+	Resolution
+
+	H      value.Float `scad:"h"`
+	D      value.Float `scad:"d"`
+	Center value.Bool  `scad:"center"`
+}
+
+func ExampleEncode_embedded() {
+	bead := Bead{
+		Body: primitive3d.Sphere{D: value.NewFloat(10)},
+		Hole: Hole{
+			Resolution: Resolution{FN: value.NewInt(12)},
+			H:          value.NewFloat(12),
+			D:          value.NewFloat(2),
+			Center:     value.NewBool(true),
+		},
+	}
+
+	content, _ := scad.FunctionContent(bead)
+	fmt.Println(content)
+	// Output: difference() {
+	//   sphere(d=10);
+	//   cylinder($fn=12, center=true, d=2, h=12);
+	// }
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.incompletion.ist/go-scad/control"
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/transformation"
+	"go.incompletion.ist/go-scad/value"
+)
+
+// Point is a type that knows nothing of scad, such as one from another package.
+type Point [3]float64
+
+func ExampleEncoder() {
+	encoder := scad.Encoder{
+		// Points are encoded as markers, even as the children of other types
+		NodeEncoders: map[reflect.Type]scad.NodeEncoderFunc{
+			reflect.TypeOf(Point{}): func(v interface{}) (interface{}, error) {
+				p := v.(Point)
+
+				return transformation.Translate{
+					V:        value.NewFloatXYZ(p[0], p[1], p[2]),
+					Children: []interface{}{primitive3d.Sphere{D: value.NewFloat(1)}},
+				}, nil
+			},
+		},
+	}
+
+	fn, _ := encoder.Encode(control.If{
+		Condition: value.Var("show_markers"),
+		Children:  []interface{}{Point{0, 0, 5}, Point{0, 5, 0}},
+	})
+
+	content, _ := scad.FunctionContent(fn)
+	fmt.Println(content)
+	// Output: if(show_markers) {
+	//   translate(v=[0, 0, 5]) {
+	//     sphere(d=1);
+	//   }
+	//   translate(v=[0, 5, 0]) {
+	//     sphere(d=1);
+	//   }
+	// }
+}
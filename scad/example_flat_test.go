@@ -0,0 +1,57 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/boolean"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/transformation"
+	"go.incompletion.ist/go-scad/value"
+)
+
+type Balls struct {
+	Name scad.ModuleName `scad:"balls"`
+}
+
+func (balls Balls) EncodeSCAD() (interface{}, error) {
+	return boolean.Union{
+		Children: []interface{}{
+			Ball{Diameter: 10},
+			scad.Apply(
+				Ball{Diameter: 10},
+				transformation.Translate{V: value.NewFloatXYZ(20, 0, 0)},
+			),
+		},
+	}, nil
+}
+
+func ExampleFlatFunctionContent() {
+	content, _ := scad.FlatFunctionContent(Balls{})
+	fmt.Println(content)
+	// Output: module ball() {
+	//   sphere(d=10);
+	// }
+	// module balls() {
+	//   union() {
+	//     ball();
+	//     translate(v=[20, 0, 0]) {
+	//       ball();
+	//     }
+	//   }
+	// }
+	// balls();
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/primitive2d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/value"
+)
+
+func ExampleFormat() {
+	w := scad.Writer{
+		Encoder: scad.Encoder{ParameterOrder: scad.DeclarationOrder},
+		Format: scad.Format{
+			Indent:     "    ",
+			Precision:  2,
+			Header:     "Generated by go-scad.",
+			LineLength: 30,
+		},
+	}
+
+	content, _ := w.FunctionContent(primitive2d.Polygon{
+		Points:    value.NewFloatsXY([2]float64{0, 0}, [2]float64{10, 0}, [2]float64{5, 8.66025}),
+		Convexity: value.NewInt(2),
+	})
+	fmt.Println(content)
+	// Output: // Generated by go-scad.
+	// polygon(
+	//     points=[
+	//         [0, 0], [10, 0],
+	//         [5, 8.66]
+	//     ],
+	//     convexity=2
+	// );
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/boolean"
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/transformation"
+	"go.incompletion.ist/go-scad/value"
+)
+
+func ExampleModifier() {
+	hole := scad.Apply(
+		primitive3d.Cylinder{
+			H: value.NewFloat(30),
+			D: value.NewFloat(5),
+		},
+		transformation.Translate{
+			V: value.NewFloatXYZ(10, 10, -5),
+		},
+		// highlight the subtracted cylinder
+		scad.Highlight,
+	)
+
+	plate := scad.Apply(
+		primitive3d.Cube{Size: value.NewFloat(20)},
+		boolean.Difference{
+			Children: []interface{}{hole},
+		},
+	)
+
+	content, _ := scad.FunctionContent(plate)
+	fmt.Println(content)
+	// Output: difference() {
+	//   cube(size=20);
+	//   #translate(v=[10, 10, -5]) {
+	//     cylinder(d=5, h=30);
+	//   }
+	// }
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/value"
+)
+
+type Ball struct {
+	Name scad.ModuleName `scad:"ball"`
+
+	// Quality is assigned at the top of the ball module's file.
+	Quality scad.Variables `scad:",file"`
+
+	Diameter float64
+}
+
+func (ball Ball) EncodeSCAD() (interface{}, error) {
+	return primitive3d.Sphere{D: value.NewFloat(ball.Diameter)}, nil
+}
+
+func ExampleVariables() {
+	ball := Ball{
+		Quality: scad.Variables{
+			{Name: "$fa", Value: 6},
+			{Name: "$fs", Value: value.NewFloat(0.5)},
+		},
+		Diameter: 10,
+	}
+
+	content, _ := scad.FunctionContent(ball)
+	fmt.Println(content)
+	// Output: $fa = 6;
+	// $fs = 0.5;
+	// module ball() {
+	//   sphere(d=10);
+	// }
+	// ball();
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+func ExampleInspect() {
+	fn := scad.Function{
+		Name: "union",
+		Children: []scad.Function{
+			{Name: "cube", Arguments: []string{"10"}},
+			{
+				Name:      "translate",
+				Arguments: []string{"[0, 0, 10]"},
+				Children:  []scad.Function{{Name: "sphere", Arguments: []string{"5"}}},
+			},
+		},
+	}
+
+	scad.Inspect(fn, func(c scad.Cursor) bool {
+		fmt.Printf("%q %s\n", c.Path.String(), c.Function.Name)
+
+		return true
+	})
+	// Output: "" union
+	// "Children[0]" cube
+	// "Children[1]" translate
+	// "Children[1].Children[0]" sphere
+}
+
+func ExampleRewrite() {
+	fn := scad.Function{
+		Name: "union",
+		Children: []scad.Function{
+			{Name: "cube", Arguments: []string{"10"}},
+			{Name: "sphere", Arguments: []string{"5"}},
+		},
+	}
+
+	// replace spheres with lower quality versions of themselves
+	rewritten := scad.Rewrite(fn, func(c scad.Cursor) scad.Function {
+		if c.Function.Name == "sphere" {
+			c.Function.SetParameter("$fn", "12")
+		}
+
+		return c.Function
+	})
+
+	content, _ := scad.FunctionContent(rewritten)
+	fmt.Println(content)
+	// Output: union() {
+	//   cube(10);
+	//   sphere(5, $fn=12);
+	// }
+}
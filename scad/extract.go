@@ -0,0 +1,338 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"strings"
+
+	"go.incompletion.ist/go-scad/internal/syntax"
+)
+
+// subtree is what ExtractModules needs to know of a Function and its descendents, found in
+// a single pass over the tree.
+type subtree struct {
+	// key identifies the subtree by a hash of its content, ignoring the Modifier of the
+	// Function itself, which applies to the call, not the definition.
+	key string
+
+	// size is the number of Functions in the subtree.
+	size int
+
+	// bindingDepth is the depth of the outermost Function that binds a name the subtree
+	// refers to, or math.MaxInt if the subtree doesn't refer to any bound names.
+	bindingDepth int
+
+	children     []subtree
+	elseChildren []subtree
+}
+
+// newSubtree returns the subtree for a Function at the given depth, within a scope mapping
+// the names bound by its ancestors to the depth of the Function binding them. Keys are
+// built bottom-up, by hashing the content of each Function without its children along with
+// the keys of its children, so each Function's content is only written once.
+func newSubtree(fn Function, depth int, scope map[string]int) subtree {
+	scope = fn.bindScope(depth, scope)
+
+	st := subtree{size: 1, bindingDepth: math.MaxInt}
+	for _, name := range fn.references() {
+		if bindingDepth, ok := scope[name]; ok && bindingDepth < st.bindingDepth {
+			st.bindingDepth = bindingDepth
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(fn.subtreeContent()))
+
+	newChildren := func(children []Function, open string) []subtree {
+		if children == nil {
+			return nil
+		}
+
+		hash.Write([]byte(open))
+
+		subtrees := make([]subtree, len(children))
+		for i, child := range children {
+			subtrees[i] = newSubtree(child, depth+1, scope)
+
+			// the keys of children ignore their Modifiers, but this subtree's key mustn't
+			fmt.Fprintf(hash, "%s%s\n", child.Modifier, subtrees[i].key)
+
+			st.size += subtrees[i].size
+			if subtrees[i].bindingDepth < st.bindingDepth {
+				st.bindingDepth = subtrees[i].bindingDepth
+			}
+		}
+
+		hash.Write([]byte("}\n"))
+
+		return subtrees
+	}
+	st.children = newChildren(fn.Children, "{\n")
+	st.elseChildren = newChildren(fn.Else, "else {\n")
+
+	st.key = fmt.Sprintf("%x", hash.Sum(nil))
+
+	return st
+}
+
+// extractable returns a boolean indicating if the subtree at the given depth can be made
+// into a module, which it can't if it refers to a name bound by one of its ancestors, as the
+// module wouldn't be in that name's scope.
+func (st subtree) extractable(depth int) bool {
+	return st.bindingDepth >= depth
+}
+
+// subtreeContent returns the content of a Function without its children or Modifier. Modules
+// are written as their call followed by the call of their body.
+func (fn Function) subtreeContent() string {
+	fn.Modifier = ""
+	fn.Children = nil
+	fn.Else = nil
+
+	var e emitter
+	e.assignments(fn.FileAssignments)
+
+	if fn.ModuleName != "" {
+		fn.emitCall(&e)
+		fn.ModuleName = ""
+	}
+	fn.emitFunctionCall(&e)
+
+	return e.buf.String()
+}
+
+// bindScope returns the scope of the Function's descendents, which is the given scope with
+// the names bound by the Function at the given depth added. These are the variables of
+// loops and let, the parameters of modules, and assignments. The assignments of a group are
+// written in its parent's scope, so they're bound at its parent's depth, along with those of
+// the groups among the Function's children. Special variables, such as "$fn", are
+// dynamically scoped, so they aren't bound.
+func (fn Function) bindScope(depth int, scope map[string]int) map[string]int {
+	var names []string
+
+	switch fn.Name {
+	case "for", "intersection_for", "let":
+		for name := range fn.Parameters {
+			names = append(names, name)
+		}
+
+		for _, argument := range fn.Arguments {
+			if name, _, ok := strings.Cut(argument, "="); ok {
+				names = append(names, strings.TrimSpace(name))
+			}
+		}
+	}
+
+	if fn.ModuleName != "" {
+		for name := range fn.ModuleParameters {
+			names = append(names, name)
+		}
+	}
+
+	for _, assignment := range fn.FileAssignments {
+		names = append(names, assignment.Name)
+	}
+
+	var assignmentNames []string
+	for _, assignment := range fn.Assignments {
+		assignmentNames = append(assignmentNames, assignment.Name)
+	}
+
+	for _, children := range [][]Function{fn.Children, fn.Else} {
+		assignmentNames = append(assignmentNames, groupAssignmentNames(children)...)
+	}
+
+	assignmentDepth := depth
+	if fn.isInlinedGroup() {
+		assignmentDepth = depth - 1
+	}
+
+	var bound map[string]int
+	bind := func(name string, bindingDepth int) {
+		if strings.HasPrefix(name, "$") {
+			return
+		}
+
+		// the scope is shared with the Function's siblings, so it is copied before binding
+		if bound == nil {
+			bound = make(map[string]int, len(scope)+len(names)+len(assignmentNames))
+			for scopeName, scopeDepth := range scope {
+				bound[scopeName] = scopeDepth
+			}
+		}
+
+		bound[name] = bindingDepth
+	}
+
+	for _, name := range names {
+		bind(name, depth)
+	}
+
+	for _, name := range assignmentNames {
+		bind(name, assignmentDepth)
+	}
+
+	if bound == nil {
+		return scope
+	}
+
+	return bound
+}
+
+// isInlinedGroup returns a boolean indicating if the Function is a group whose content is
+// written in place of it, in its parent's scope, which it is unless it is a module.
+func (fn Function) isInlinedGroup() bool {
+	return fn.isGroup() && fn.ModuleName == ""
+}
+
+// groupAssignmentNames returns the names assigned by the inlined groups among the given
+// Functions, including the groups nested within them, as they're assigned in the scope the
+// Functions are written in.
+func groupAssignmentNames(fns []Function) []string {
+	var names []string
+
+	for _, fn := range fns {
+		if !fn.isInlinedGroup() {
+			continue
+		}
+
+		for _, assignment := range fn.Assignments {
+			names = append(names, assignment.Name)
+		}
+
+		names = append(names, groupAssignmentNames(fn.Children)...)
+	}
+
+	return names
+}
+
+// references returns the names referred to by the values of the Function's arguments,
+// parameters, and assignments.
+func (fn Function) references() []string {
+	var references []string
+
+	for _, argument := range fn.Arguments {
+		references = append(references, syntax.Identifiers(argument)...)
+	}
+
+	for _, value := range fn.Parameters {
+		references = append(references, syntax.Identifiers(value)...)
+	}
+
+	for _, value := range fn.ModuleParameters {
+		references = append(references, syntax.Identifiers(value)...)
+	}
+
+	for _, assignment := range fn.Assignments {
+		references = append(references, syntax.Identifiers(assignment.Value)...)
+	}
+
+	for _, assignment := range fn.FileAssignments {
+		references = append(references, syntax.Identifiers(assignment.Value)...)
+	}
+
+	return references
+}
+
+// extractedModuleName returns the name of the module extracted for a subtree, derived from
+// its Name and its key.
+func extractedModuleName(fn Function, key string) string {
+	prefix := fn.Name
+	if prefix == "" {
+		prefix = "group"
+	}
+
+	return fmt.Sprintf("%s_%s", prefix, key[:12])
+}
+
+// ExtractModules returns a copy of the Function with subtrees of at least minSize Functions
+// that have identical content, and occur more than once, made into modules. Module names
+// are derived from a hash of the subtree's content, so they are stable for the same content.
+// Modifiers are kept on the calls of the new modules, so subtrees that differ only by their
+// Modifier share a module.
+//
+// Subtrees that refer to a name bound by one of their ancestors, such as the variable of an
+// enclosing for loop, a parameter of an enclosing module, or an assignment, aren't
+// extracted, as the name wouldn't be in scope in the module. Special variables, such as
+// "$fn", are dynamically scoped, so they don't prevent extraction.
+//
+// Subtrees found within an extracted subtree are only extracted themselves if they also
+// occur elsewhere. Existing modules are kept, but subtrees within them may be extracted. An
+// error is returned if the resulting tree has conflicting module definitions.
+func (fn Function) ExtractModules(minSize int) (Function, error) {
+	root := newSubtree(fn, 0, nil)
+
+	counts := map[string]int{}
+
+	var count func(st subtree, depth int)
+	count = func(st subtree, depth int) {
+		if st.extractable(depth) {
+			counts[st.key]++
+		}
+
+		for _, child := range st.children {
+			count(child, depth+1)
+		}
+		for _, child := range st.elseChildren {
+			count(child, depth+1)
+		}
+	}
+	count(root, 0)
+
+	var extract func(extractFn Function, st subtree, depth int, extractedCount int) Function
+	extract = func(extractFn Function, st subtree, depth int, extractedCount int) Function {
+		key := st.key
+
+		if extractFn.ModuleName == "" && st.extractable(depth) && counts[key] > 1 && counts[key] > extractedCount && st.size >= minSize {
+			extractFn.ModuleName = extractedModuleName(extractFn, key)
+			extractedCount = counts[key]
+		}
+
+		extractFn.Children = extractChildren(extractFn.Children, func(i int, child Function) Function {
+			return extract(child, st.children[i], depth+1, extractedCount)
+		})
+		extractFn.Else = extractChildren(extractFn.Else, func(i int, child Function) Function {
+			return extract(child, st.elseChildren[i], depth+1, extractedCount)
+		})
+
+		return extractFn
+	}
+
+	extracted := extract(fn, root, 0, 0)
+
+	if _, err := extracted.allModules(); err != nil {
+		return Function{}, err
+	}
+
+	return extracted, nil
+}
+
+// extractChildren returns a new slice of the result of calling extract for each child and
+// its index.
+func extractChildren(children []Function, extract func(int, Function) Function) []Function {
+	if children == nil {
+		return nil
+	}
+
+	extracted := make([]Function, len(children))
+	for i, child := range children {
+		extracted[i] = extract(i, child)
+	}
+
+	return extracted
+}
@@ -0,0 +1,310 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testHole returns a subtree of size 2 that is repeated in tests.
+func testHole() Function {
+	return Function{
+		Name:       "translate",
+		Parameters: map[string]string{"v": "[1, 1, 0]"},
+		Children:   []Function{{Name: "cylinder", Parameters: map[string]string{"d": "3", "h": "10"}}},
+	}
+}
+
+func TestFunction_ExtractModules(t *testing.T) {
+	highlightedHole := testHole()
+	highlightedHole.Modifier = Highlight
+
+	input := Function{
+		Name: "difference",
+		Children: []Function{
+			{Name: "cube", Parameters: map[string]string{"size": "20"}},
+			testHole(),
+			highlightedHole,
+			{Name: "cube", Parameters: map[string]string{"size": "20"}},
+		},
+	}
+
+	got, err := input.ExtractModules(2)
+	if err != nil {
+		t.Fatalf("ExtractModules() returned error: %s", err)
+	}
+
+	holeModuleName := got.Children[1].ModuleName
+	if !strings.HasPrefix(holeModuleName, "translate_") {
+		t.Fatalf("ExtractModules() named module %q, want prefix %q", holeModuleName, "translate_")
+	}
+
+	wantHole := testHole()
+	wantHole.ModuleName = holeModuleName
+	wantHighlightedHole := wantHole
+	wantHighlightedHole.Modifier = Highlight
+
+	want := Function{
+		Name: "difference",
+		Children: []Function{
+			// too small to be extracted
+			{Name: "cube", Parameters: map[string]string{"size": "20"}},
+			wantHole,
+			wantHighlightedHole,
+			{Name: "cube", Parameters: map[string]string{"size": "20"}},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractModules() got\n%#v, want\n%#v", got, want)
+	}
+
+	if input.Children[1].ModuleName != "" {
+		t.Errorf("ExtractModules() modified its input")
+	}
+
+	again, err := input.ExtractModules(2)
+	if err != nil {
+		t.Fatalf("ExtractModules() returned error: %s", err)
+	}
+
+	if again.Children[1].ModuleName != holeModuleName {
+		t.Errorf("ExtractModules() named module %q, then %q", holeModuleName, again.Children[1].ModuleName)
+	}
+}
+
+func TestFunction_ExtractModules_nested(t *testing.T) {
+	plate := Function{
+		Name:     "difference",
+		Children: []Function{{Name: "cube"}, testHole(), testHole()},
+	}
+
+	input := Function{
+		Name:     "union",
+		Children: []Function{plate, plate},
+	}
+
+	got, err := input.ExtractModules(1)
+	if err != nil {
+		t.Fatalf("ExtractModules() returned error: %s", err)
+	}
+
+	for _, gotPlate := range got.Children {
+		if !strings.HasPrefix(gotPlate.ModuleName, "difference_") {
+			t.Errorf("ExtractModules() didn't extract the repeated plate")
+		}
+
+		// the cube only occurs within plates
+		if gotPlate.Children[0].ModuleName != "" {
+			t.Errorf("ExtractModules() extracted a subtree only found within an extracted subtree")
+		}
+
+		for _, gotHole := range gotPlate.Children[1:] {
+			// each plate has two holes, so they occur more often than the plate itself
+			if !strings.HasPrefix(gotHole.ModuleName, "translate_") {
+				t.Errorf("ExtractModules() didn't extract the repeated hole within the plate")
+			}
+
+			// the cylinder only occurs within holes
+			if gotHole.Children[0].ModuleName != "" {
+				t.Errorf("ExtractModules() extracted a subtree only found within an extracted subtree")
+			}
+		}
+	}
+
+	if _, err := (Format{}).FlatContent(got); err != nil {
+		t.Errorf("FlatContent() of extracted Function returned error: %s", err)
+	}
+}
+
+func TestFunction_ExtractModules_minSize(t *testing.T) {
+	input := Function{
+		Name:     "union",
+		Children: []Function{testHole(), testHole()},
+	}
+
+	got, err := input.ExtractModules(3)
+	if err != nil {
+		t.Fatalf("ExtractModules() returned error: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, input) {
+		t.Errorf("ExtractModules() got\n%#v, want\n%#v", got, input)
+	}
+}
+
+// extractedNames returns the Names of the Functions of a tree that were made into modules,
+// other than those of the given existing modules, in the order they are found.
+func extractedNames(fn Function, existing ...string) []string {
+	var names []string
+
+	Inspect(fn, func(c Cursor) bool {
+		if c.Function.ModuleName == "" {
+			return true
+		}
+
+		for _, moduleName := range existing {
+			if c.Function.ModuleName == moduleName {
+				return true
+			}
+		}
+
+		names = append(names, c.Function.Name)
+
+		return true
+	})
+
+	return names
+}
+
+func TestFunction_ExtractModules_scope(t *testing.T) {
+	// loop returns for(i=values) translate(v=[i, 0, 0]) cube(1);
+	loop := func(variable string, values string) Function {
+		return Function{
+			Name:       "for",
+			Parameters: map[string]string{variable: values},
+			Children: []Function{
+				{
+					Name:       "translate",
+					Parameters: map[string]string{"v": "[i, 0, 0]"},
+					Children:   []Function{{Name: "cube", Arguments: []string{"1"}}},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		input    Function
+		existing []string
+		want     []string
+	}{
+		{
+			name: "identical loops",
+			input: Function{
+				Name:     "union",
+				Children: []Function{loop("i", "[0:3]"), loop("i", "[0:3]")},
+			},
+			// the loops bind the variable they refer to
+			want: []string{"for", "for"},
+		},
+		{
+			name: "loop variable",
+			input: Function{
+				Name:     "union",
+				Children: []Function{loop("i", "[0:3]"), loop("i", "[0:5]")},
+			},
+			// the translations refer to the loop variable, but the cubes don't
+			want: []string{"cube", "cube"},
+		},
+		{
+			name: "let arguments",
+			input: Function{
+				Name: "union",
+				Children: []Function{
+					{Name: "let", Arguments: []string{"i=2"}, Children: loop("j", "[0:3]").Children},
+					{Name: "let", Arguments: []string{"i=3"}, Children: loop("j", "[0:3]").Children},
+				},
+			},
+			want: []string{"cube", "cube"},
+		},
+		{
+			name: "module parameters",
+			input: Function{
+				ModuleName:       "box",
+				Name:             "union",
+				ModuleParameters: map[string]string{"size": "10"},
+				Children: []Function{
+					{Name: "cube", Parameters: map[string]string{"size": "size"}},
+					{Name: "cube", Parameters: map[string]string{"size": "size"}},
+				},
+			},
+			existing: []string{"box"},
+		},
+		{
+			name: "assignments",
+			input: Function{
+				Name:        "union",
+				Assignments: []Assignment{{Name: "size", Value: "10"}, {Name: "$fn", Value: "16"}},
+				Children: []Function{
+					{Name: "cube", Parameters: map[string]string{"size": "size"}},
+					{Name: "cube", Parameters: map[string]string{"size": "size"}},
+					{Name: "sphere", Parameters: map[string]string{"$fn": "$fn"}},
+					{Name: "sphere", Parameters: map[string]string{"$fn": "$fn"}},
+				},
+			},
+			// special variables are dynamically scoped
+			want: []string{"sphere", "sphere"},
+		},
+		{
+			name: "group assignments",
+			input: Function{
+				Name: "union",
+				Children: []Function{
+					{
+						Assignments: []Assignment{{Name: "size", Value: "10"}},
+						Children:    []Function{{Assignments: []Assignment{{Name: "r", Value: "2"}}}},
+					},
+					{Name: "cube", Parameters: map[string]string{"size": "size"}},
+					{Name: "cube", Parameters: map[string]string{"size": "size"}},
+					{Name: "sphere", Parameters: map[string]string{"r": "r"}},
+					{Name: "sphere", Parameters: map[string]string{"r": "r"}},
+				},
+			},
+			// the group's assignments, and those of its groups, are made in the union's scope
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.input.ExtractModules(1)
+		if err != nil {
+			t.Errorf("%q ExtractModules() returned error: %s", test.name, err)
+
+			continue
+		}
+
+		if gotNames := extractedNames(got, test.existing...); !reflect.DeepEqual(gotNames, test.want) {
+			t.Errorf("%q ExtractModules() extracted\n%#v, want\n%#v", test.name, gotNames, test.want)
+		}
+	}
+}
+
+func TestFunction_ExtractModules_content(t *testing.T) {
+	// the same content with different parameter orders is written differently
+	input := Function{
+		Name: "union",
+		Children: []Function{
+			{Name: "cube", Parameters: map[string]string{"size": "1", "center": "true"}},
+			{Name: "cube", Parameters: map[string]string{"size": "1", "center": "true"}, ParameterOrder: []string{"size"}},
+			{Name: "cube", Parameters: map[string]string{"center": "true", "size": "1"}},
+		},
+	}
+
+	got, err := input.ExtractModules(1)
+	if err != nil {
+		t.Fatalf("ExtractModules() returned error: %s", err)
+	}
+
+	moduleNames := make([]string, len(got.Children))
+	for i, child := range got.Children {
+		moduleNames[i] = child.ModuleName
+	}
+
+	if moduleNames[0] == "" || moduleNames[0] != moduleNames[2] || moduleNames[1] != "" {
+		t.Errorf("ExtractModules() made modules %#v, want the first and last to share one", moduleNames)
+	}
+}
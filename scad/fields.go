@@ -0,0 +1,235 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"reflect"
+	"sync"
+)
+
+// reflected interface types, checked against struct field types by Encode.
+var (
+	moduleNameGetterType     = reflect.TypeOf((*ModuleNameGetter)(nil)).Elem()
+	variablesGetterType      = reflect.TypeOf((*VariablesGetter)(nil)).Elem()
+	modifierGetterType       = reflect.TypeOf((*ModifierGetter)(nil)).Elem()
+	functionNameGetterType   = reflect.TypeOf((*FunctionNameGetter)(nil)).Elem()
+	parameterValueGetterType = reflect.TypeOf((*ParameterValueGetter)(nil)).Elem()
+	scadEncoderType          = reflect.TypeOf((*SCADEncoder)(nil)).Elem()
+)
+
+// fieldKind describes which of the interfaces checked by Encode a type implements, and
+// whether it is a slice.
+type fieldKind struct {
+	isModuleNameGetter     bool
+	isVariablesGetter      bool
+	isModifierGetter       bool
+	isFunctionNameGetter   bool
+	isParameterValueGetter bool
+	isSlice                bool
+}
+
+// newFieldKind returns the fieldKind of a type.
+func newFieldKind(t reflect.Type) fieldKind {
+	return fieldKind{
+		isModuleNameGetter:     t.Implements(moduleNameGetterType),
+		isVariablesGetter:      t.Implements(variablesGetterType),
+		isModifierGetter:       t.Implements(modifierGetterType),
+		isFunctionNameGetter:   t.Implements(functionNameGetterType),
+		isParameterValueGetter: t.Implements(parameterValueGetterType),
+		isSlice:                t.Kind() == reflect.Slice,
+	}
+}
+
+// parameterEncoderKind is the fieldKind of fields whose types have a registered
+// ParameterEncoderFunc, which are encoded as parameters like ParameterValueGetters.
+var parameterEncoderKind = fieldKind{isParameterValueGetter: true}
+
+// structField is the metadata of a struct field that is used by Encode, which only depends
+// on the struct's type.
+type structField struct {
+	// field is the struct field, whose Index is its index within the encoded struct, through
+	// any flattened embedded structs.
+	field reflect.StructField
+
+	// name and opts are parsed from the field's "scad" tag.
+	name string
+	opts tagOptions
+
+	// isChild indicates a single child field, which is a non-slice field with the "child"
+	// option in its "scad" tag.
+	isChild bool
+
+	isModuleParameter bool
+	isCustomizable    bool
+	hasChecks         bool
+
+	// customizerParameter is the field's CustomizerParameter, or the error building it, if
+	// the field is customizable.
+	customizerParameter    CustomizerParameter
+	customizerParameterErr error
+
+	// kind is the fieldKind of the field's type, and elemKind that of its element type for
+	// pointer fields, which are dereferenced when not nil.
+	kind     fieldKind
+	elemKind fieldKind
+}
+
+// structType is the metadata of a struct type that is used by Encode.
+type structType struct {
+	// fields is the metadata of the type's fields, in order, except those with a "scad" tag
+	// of "-". The fields of flattened embedded structs are in place of the embedded struct.
+	fields []structField
+
+	// flattened is the types of the embedded struct fields that were flattened.
+	flattened []reflect.Type
+
+	isSCADEncoder bool
+}
+
+// structTypeCache caches the structType of each type encoded, like encoding/json does.
+var structTypeCache sync.Map // map[reflect.Type]*structType
+
+// cachedStructType returns the structType of a struct type, building it on first use. As it
+// only depends on the type, embedded structs are flattened whatever encoders are registered
+// for them.
+func cachedStructType(t reflect.Type) *structType {
+	if cached, ok := structTypeCache.Load(t); ok {
+		return cached.(*structType)
+	}
+
+	st := newStructType(t, func(reflect.Type) bool { return false })
+
+	cached, _ := structTypeCache.LoadOrStore(t, st)
+
+	return cached.(*structType)
+}
+
+// structType returns the structType of a struct type as encoded by the Encoder, whose
+// registered encoders prevent the embedded structs they're registered for from being
+// flattened. The cached structType is used unless one of its flattened types has one.
+func (enc Encoder) structType(t reflect.Type) *structType {
+	st := cachedStructType(t)
+
+	for _, embeddedT := range st.flattened {
+		if enc.hasEncoder(embeddedT) {
+			return newStructType(t, enc.hasEncoder)
+		}
+	}
+
+	return st
+}
+
+// newStructType returns the structType of a struct type, where embedded structs of types
+// for which isEncoded returns true aren't flattened.
+func newStructType(t reflect.Type, isEncoded func(reflect.Type) bool) *structType {
+	st := &structType{isSCADEncoder: t.Implements(scadEncoderType)}
+	st.fields = st.appendFields(nil, t, nil, map[reflect.Type]bool{t: true}, isEncoded)
+
+	return st
+}
+
+// isFlattened returns a boolean indicating if an embedded struct field's fields are encoded
+// as if they were fields of the struct embedding it. Only exported, untagged embedded structs
+// that don't implement any of the interfaces checked by Encode, and for which isEncoded
+// returns false, are flattened, like encoding/json.
+func isFlattened(field reflect.StructField, isEncoded func(reflect.Type) bool) bool {
+	if !field.Anonymous || !field.IsExported() || field.Tag.Get("scad") != "" {
+		return false
+	}
+
+	fieldT := field.Type
+	if fieldT.Kind() == reflect.Ptr {
+		fieldT = fieldT.Elem()
+	}
+
+	if isEncoded(field.Type) || isEncoded(fieldT) {
+		return false
+	}
+
+	return fieldT.Kind() == reflect.Struct && newFieldKind(field.Type) == fieldKind{} && newFieldKind(fieldT) == fieldKind{}
+}
+
+// appendFields appends the structFields of the struct type t, whose fields are found at
+// index within the encoded struct, flattening embedded structs and recording their types.
+// The types being flattened are tracked by seen, to ignore recursively embedded types.
+func (st *structType) appendFields(fields []structField, t reflect.Type, index []int, seen map[reflect.Type]bool, isEncoded func(reflect.Type) bool) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		field.Index = append(append([]int{}, index...), i)
+
+		if isFlattened(field, isEncoded) {
+			st.flattened = append(st.flattened, field.Type)
+
+			embeddedT := field.Type
+			if embeddedT.Kind() == reflect.Ptr {
+				embeddedT = embeddedT.Elem()
+			}
+
+			if !seen[embeddedT] {
+				seen[embeddedT] = true
+				fields = st.appendFields(fields, embeddedT, field.Index, seen, isEncoded)
+				delete(seen, embeddedT)
+			}
+
+			continue
+		}
+
+		name, opts := parseTag(field)
+		if name == "-" {
+			continue
+		}
+
+		sf := structField{
+			field:          field,
+			name:           name,
+			opts:           opts,
+			isChild:        opts.has("child") && field.Type.Kind() != reflect.Slice,
+			isCustomizable: opts.has("customize"),
+			hasChecks:      opts.hasChecks(),
+			kind:           newFieldKind(field.Type),
+		}
+		sf.isModuleParameter = opts.has("parameter") || sf.isCustomizable
+
+		if sf.isCustomizable {
+			sf.customizerParameter, sf.customizerParameterErr = customizerParameter(name, field, opts)
+		}
+
+		if field.Type.Kind() == reflect.Ptr {
+			sf.elemKind = newFieldKind(field.Type.Elem())
+		}
+
+		fields = append(fields, sf)
+	}
+
+	return fields
+}
+
+// fieldByIndex returns the field of v at index, which may be within embedded structs, and a
+// boolean indicating if it was found, which it isn't if an embedded struct pointer is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(fieldIndex)
+	}
+
+	return v, true
+}
@@ -0,0 +1,164 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"strconv"
+	"strings"
+
+	"go.incompletion.ist/go-scad/internal/syntax"
+)
+
+// Format is the style of written OpenSCAD content. The zero value is the default style,
+// which is used by FunctionContent, Write, and the other functions of this package.
+type Format struct {
+	// Indent is the indentation of each level of nesting, such as "\t" or four spaces. The
+	// default is two spaces.
+	Indent string
+
+	// Precision is the maximum number of digits after the decimal point of the numbers in
+	// parameter, argument, and assignment values, which are rounded to it. Zero leaves
+	// numbers as they were encoded.
+	Precision int
+
+	// TrailingZeros pads rounded numbers with a decimal point to Precision digits, such as
+	// "1.500", instead of removing trailing zeros, such as "1.5".
+	TrailingZeros bool
+
+	// Header is a comment written at the top of each file, such as a license or a note that
+	// the file is generated. Each of its lines is written as a "//" comment.
+	Header string
+
+	// OmitSelfCall omits the call of a module at the end of its own file, which is written
+	// by default so that any module's file can be opened in OpenSCAD on its own. Flat
+	// content still ends by calling its module, as that is its only content.
+	OmitSelfCall bool
+
+	// LineLength is the length at which the lines of calls are wrapped, writing each
+	// argument on its own line, and the elements of vectors that are still too long, such as
+	// the points of a polygon, on as few lines as fit. Zero doesn't wrap lines.
+	LineLength int
+}
+
+// Content returns the OpenSCAD content of a Function's file, as written by Write.
+func (format Format) Content(fn Function) (string, error) {
+	e := emitter{format: format}
+	if err := fn.emitFile(&e); err != nil {
+		return "", err
+	}
+
+	return e.buf.String(), nil
+}
+
+// FlatContent returns the OpenSCAD content of a Function as a single self-contained file,
+// as written by WriteFlat.
+func (format Format) FlatContent(fn Function) (string, error) {
+	e := emitter{format: format}
+	if err := fn.emitFlat(&e); err != nil {
+		return "", err
+	}
+
+	return e.buf.String(), nil
+}
+
+// indent returns the indentation of each level of nesting.
+func (format Format) indent() string {
+	if format.Indent == "" {
+		return "  "
+	}
+
+	return format.Indent
+}
+
+// value returns a parameter, argument, or assignment value with its numbers rounded to the
+// Format's Precision. Numbers within string literals and identifiers, such as "$fn" or "r1",
+// are left as they are.
+func (format Format) value(value string) string {
+	if format.Precision <= 0 {
+		return value
+	}
+
+	var formatted strings.Builder
+	var inString, escaped bool
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case inString:
+			formatted.WriteByte(c)
+
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			formatted.WriteByte(c)
+			inString = true
+		case syntax.IsIdentifierStart(c):
+			end := i + 1
+			for end < len(value) && syntax.IsIdentifierPart(value[end]) {
+				end++
+			}
+
+			formatted.WriteString(value[i:end])
+			i = end - 1
+		case syntax.IsDigit(c) || (c == '.' && i+1 < len(value) && syntax.IsDigit(value[i+1])):
+			end := i + syntax.NumberLength(value[i:])
+
+			formatted.WriteString(format.number(value[i:end]))
+			i = end - 1
+		default:
+			formatted.WriteByte(c)
+		}
+	}
+
+	return formatted.String()
+}
+
+// number returns a number literal rounded to the Format's Precision. Integers are left as
+// they are.
+func (format Format) number(literal string) string {
+	if !strings.ContainsAny(literal, ".eE") {
+		return literal
+	}
+
+	number, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return literal
+	}
+
+	rounded := strconv.FormatFloat(number, 'f', format.Precision, 64)
+	if !format.TrailingZeros {
+		rounded = strings.TrimRight(rounded, "0")
+		rounded = strings.TrimSuffix(rounded, ".")
+	}
+
+	return rounded
+}
+
+// vectorElements returns the elements of a vector value, such as "[[0, 0], [1, 0]]", and a
+// boolean indicating if the value is a vector.
+func vectorElements(value string) ([]string, bool) {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, false
+	}
+
+	return syntax.SplitElements(value[1 : len(value)-1])
+}
@@ -0,0 +1,230 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import "testing"
+
+func TestFormat_Content(t *testing.T) {
+	module := Function{
+		ModuleName:       "peg",
+		Name:             "translate",
+		Parameters:       map[string]string{"v": "[0, 0, 1.23456]"},
+		ModuleParameters: map[string]string{"r": "2.5", "h": "10"},
+		Children: []Function{
+			{Name: "cylinder", Parameters: map[string]string{"h": "h", "r": "r", "$fn": "24"}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		format Format
+		input  Function
+		want   string
+	}{
+		{
+			name:   "default",
+			format: Format{},
+			input:  module,
+			want: `module peg(h=10, r=2.5) {
+  translate(v=[0, 0, 1.23456]) {
+    cylinder($fn=24, h=h, r=r);
+  }
+}
+peg();
+`,
+		},
+		{
+			name:   "indent",
+			format: Format{Indent: "\t"},
+			input:  module,
+			want: `module peg(h=10, r=2.5) {
+	translate(v=[0, 0, 1.23456]) {
+		cylinder($fn=24, h=h, r=r);
+	}
+}
+peg();
+`,
+		},
+		{
+			name:   "precision",
+			format: Format{Precision: 2},
+			input:  module,
+			want: `module peg(h=10, r=2.5) {
+  translate(v=[0, 0, 1.23]) {
+    cylinder($fn=24, h=h, r=r);
+  }
+}
+peg();
+`,
+		},
+		{
+			name:   "trailing zeros",
+			format: Format{Precision: 3, TrailingZeros: true},
+			input:  module,
+			want: `module peg(h=10, r=2.500) {
+  translate(v=[0, 0, 1.235]) {
+    cylinder($fn=24, h=h, r=r);
+  }
+}
+peg();
+`,
+		},
+		{
+			name:   "precision leaves strings and identifiers",
+			format: Format{Precision: 1},
+			input: Function{
+				Name:        "text",
+				Parameters:  map[string]string{"text": `"v1.25"`, "size": "x1 + 0.25e1"},
+				Assignments: []Assignment{{Name: "x1", Value: "0.06"}},
+				Children:    []Function{{Name: "cube", Arguments: []string{"1.04"}}},
+			},
+			want: `text(size=x1 + 2.5, text="v1.25") {
+  x1 = 0.1;
+  cube(1);
+}
+`,
+		},
+		{
+			name:   "header",
+			format: Format{Header: "Generated file.\n\nDo not edit.\n"},
+			input:  Function{Name: "cube"},
+			want: `// Generated file.
+//
+// Do not edit.
+cube();
+`,
+		},
+		{
+			name:   "omit self call",
+			format: Format{OmitSelfCall: true},
+			input:  module,
+			want: `module peg(h=10, r=2.5) {
+  translate(v=[0, 0, 1.23456]) {
+    cylinder($fn=24, h=h, r=r);
+  }
+}
+`,
+		},
+		{
+			name:   "parameter order",
+			format: Format{},
+			input: Function{
+				Name:           "cylinder",
+				Parameters:     map[string]string{"h": "1", "r": "2", "center": "true", "$fn": "6"},
+				ParameterOrder: []string{"r", "h", "d"},
+			},
+			want: `cylinder(r=2, h=1, $fn=6, center=true);
+`,
+		},
+		{
+			name:   "customizer parameters",
+			format: Format{Precision: 2, TrailingZeros: true},
+			input: Function{
+				ModuleName:       "peg",
+				Name:             "cylinder",
+				Parameters:       map[string]string{"h": "h", "r": "r"},
+				ModuleParameters: map[string]string{"r": "2.5", "h": "10.126"},
+				ParameterOrder:   []string{"r", "h"},
+				CustomizerParameters: []CustomizerParameter{
+					{Name: "h", Widget: "[1:20]"},
+					{Name: "r"},
+				},
+			},
+			want: `h = 10.13; // [1:20]
+r = 2.50;
+module peg(r=2.50, h=10.13) {
+  cylinder(r=r, h=h);
+}
+peg(r=r, h=h);
+`,
+		},
+		{
+			name:   "line length fits",
+			format: Format{LineLength: 40},
+			input:  Function{Name: "polygon", Parameters: map[string]string{"points": "[[0, 0], [1, 0], [0, 1]]"}},
+			want: `polygon(points=[[0, 0], [1, 0], [0, 1]]);
+`,
+		},
+		{
+			name:   "line length wraps arguments",
+			format: Format{LineLength: 40},
+			input: Function{
+				Name:       "polygon",
+				Parameters: map[string]string{"points": "[[0, 0], [1, 0], [0, 1]]", "convexity": "2"},
+				Children:   []Function{{Name: "cube"}},
+			},
+			want: `polygon(
+  convexity=2,
+  points=[[0, 0], [1, 0], [0, 1]]
+) {
+  cube();
+}
+`,
+		},
+		{
+			name:   "line length wraps vectors",
+			format: Format{LineLength: 21},
+			input: Function{
+				Name:       "polygon",
+				Parameters: map[string]string{"points": "[[0, 0], [10, 0], [10, 10], [0, 10]]", "convexity": "2"},
+			},
+			want: `polygon(
+  convexity=2,
+  points=[
+    [0, 0], [10, 0],
+    [10, 10], [0, 10]
+  ]
+);
+`,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.format.Content(test.input)
+		if err != nil {
+			t.Errorf("%q Content() returned error: %s", test.name, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("%q Content() got\n%s, want\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFormat_FlatContent(t *testing.T) {
+	input := Function{
+		ModuleName: "box",
+		Name:       "cube",
+		Arguments:  []string{"10.125"},
+	}
+
+	format := Format{Header: "box", Precision: 1, OmitSelfCall: true}
+	want := `// box
+module box() {
+  cube(10.1);
+}
+box();
+`
+
+	got, err := format.FlatContent(input)
+	if err != nil {
+		t.Fatalf("FlatContent() returned error: %s", err)
+	}
+
+	if got != want {
+		t.Errorf("FlatContent() got\n%s, want\n%s", got, want)
+	}
+}
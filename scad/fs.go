@@ -0,0 +1,274 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FS is the interface for file systems that Functions can be written to.
+type FS interface {
+	// WriteFile writes data to the named file, creating it and any parent directories as
+	// needed. Names are slash-separated paths, as accepted by fs.ValidPath.
+	WriteFile(name string, data []byte) error
+}
+
+// RemoveFS is an FS that files can also be read from and removed from, which enables a
+// Writer to remove stale files.
+type RemoveFS interface {
+	FS
+
+	// ReadFile returns the content of the named file.
+	ReadFile(name string) ([]byte, error)
+
+	// Remove removes the named file.
+	Remove(name string) error
+}
+
+// validateName returns an error if name isn't valid for an FS.
+func validateName(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return nil
+}
+
+// DirFS is a RemoveFS for the operating system's directory tree rooted at the directory
+// it names.
+type DirFS string
+
+// path returns the operating system path of the named file.
+func (dir DirFS) path(name string) string {
+	return filepath.Join(string(dir), filepath.FromSlash(name))
+}
+
+// WriteFile writes data to the named file below the DirFS directory. The file is written
+// atomically, by writing a temporary file in the same directory and renaming it. If the
+// file already has the given content it is left untouched, so its modification time only
+// changes when its content does. New files are created with mode 0666, less the umask, as
+// by os.WriteFile, and existing files keep their mode.
+func (dir DirFS) WriteFile(name string, data []byte) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	p := dir.path(name)
+
+	if existing, err := os.ReadFile(p); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+
+	perm := fs.FileMode(0666) &^ umask()
+	if info, err := os.Stat(p); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+		return err
+	}
+
+	tempF, err := os.CreateTemp(filepath.Dir(p), fmt.Sprintf(".%s.*.tmp", filepath.Base(p)))
+	if err != nil {
+		return err
+	}
+	tempP := tempF.Name()
+
+	_, err = tempF.Write(data)
+	if err == nil {
+		err = tempF.Chmod(perm)
+	}
+	if closeErr := tempF.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempP, p)
+	}
+
+	if err != nil {
+		_ = os.Remove(tempP)
+
+		return err
+	}
+
+	return nil
+}
+
+// ReadFile returns the content of the named file below the DirFS directory.
+func (dir DirFS) ReadFile(name string) ([]byte, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(dir.path(name))
+}
+
+// Remove removes the named file below the DirFS directory, along with any of its parent
+// directories that are left empty, up to the DirFS directory itself.
+func (dir DirFS) Remove(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(dir.path(name)); err != nil {
+		return err
+	}
+
+	for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+		// stop at the first directory that isn't empty
+		if err := os.Remove(dir.path(parent)); err != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+// MapFS is an in-memory RemoveFS, mapping file names to their content.
+type MapFS map[string][]byte
+
+// WriteFile stores data as the content of the named file.
+func (m MapFS) WriteFile(name string, data []byte) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	m[name] = append([]byte(nil), data...)
+
+	return nil
+}
+
+// ReadFile returns the content of the named file.
+func (m MapFS) ReadFile(name string) ([]byte, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return append([]byte(nil), data...), nil
+}
+
+// Remove removes the named file.
+func (m MapFS) Remove(name string) error {
+	if _, ok := m[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	delete(m, name)
+
+	return nil
+}
+
+// archiveNames tracks the names written to an archive, which can't be overwritten.
+type archiveNames map[string]bool
+
+// add validates name and records it as written, returning an error if it already was.
+func (names archiveNames) add(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	if names[name] {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrExist}
+	}
+	names[name] = true
+
+	return nil
+}
+
+// ZipFS is an FS that writes files to a zip archive. Close must be called to finish
+// writing the archive.
+type ZipFS struct {
+	w     *zip.Writer
+	names archiveNames
+}
+
+// NewZipFS returns a new ZipFS writing a zip archive to w.
+func NewZipFS(w io.Writer) *ZipFS {
+	return &ZipFS{
+		w:     zip.NewWriter(w),
+		names: archiveNames{},
+	}
+}
+
+// WriteFile adds the named file to the archive. A file can only be written once.
+func (z *ZipFS) WriteFile(name string, data []byte) error {
+	if err := z.names.add(name); err != nil {
+		return err
+	}
+
+	fileW, err := z.w.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = fileW.Write(data)
+
+	return err
+}
+
+// Close finishes writing the zip archive. It does not close the underlying writer.
+func (z *ZipFS) Close() error {
+	return z.w.Close()
+}
+
+// TarFS is an FS that writes files to a tar archive. Close must be called to finish
+// writing the archive.
+type TarFS struct {
+	w     *tar.Writer
+	names archiveNames
+}
+
+// NewTarFS returns a new TarFS writing a tar archive to w.
+func NewTarFS(w io.Writer) *TarFS {
+	return &TarFS{
+		w:     tar.NewWriter(w),
+		names: archiveNames{},
+	}
+}
+
+// WriteFile adds the named file to the archive. A file can only be written once.
+func (t *TarFS) WriteFile(name string, data []byte) error {
+	if err := t.names.add(name); err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+	}
+	if err := t.w.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := t.w.Write(data)
+
+	return err
+}
+
+// Close finishes writing the tar archive. It does not close the underlying writer.
+func (t *TarFS) Close() error {
+	return t.w.Close()
+}
@@ -0,0 +1,191 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFS_WriteFile_invalidNames(t *testing.T) {
+	fileSystems := map[string]FS{
+		"DirFS": DirFS(t.TempDir()),
+		"MapFS": MapFS{},
+		"ZipFS": NewZipFS(io.Discard),
+		"TarFS": NewTarFS(io.Discard),
+	}
+
+	for fsName, fsys := range fileSystems {
+		for _, name := range []string{"", ".", "/abs.scad", "../escape.scad", "a//b.scad"} {
+			if err := fsys.WriteFile(name, nil); err == nil {
+				t.Errorf("%s WriteFile(%q) returned no error", fsName, name)
+			}
+		}
+	}
+}
+
+func TestDirFS_WriteFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := DirFS(dir).WriteFile("a/b/c.scad", []byte("cube();")); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "a", "b", "c.scad"))
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %s", err)
+	}
+
+	if string(got) != "cube();" {
+		t.Errorf("WriteFile() wrote %q, want %q", got, "cube();")
+	}
+}
+
+func TestDirFS_WriteFile_mode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes aren't supported on windows")
+	}
+
+	dir := t.TempDir()
+	fsys := DirFS(dir)
+
+	if err := fsys.WriteFile("new.scad", []byte("cube();")); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	// the mode os.WriteFile creates files with
+	wantP := filepath.Join(dir, "want.scad")
+	if err := os.WriteFile(wantP, []byte("cube();"), 0666); err != nil {
+		t.Fatalf("os.WriteFile() returned error: %s", err)
+	}
+
+	got, err := os.Stat(filepath.Join(dir, "new.scad"))
+	if err != nil {
+		t.Fatalf("Stat() returned error: %s", err)
+	}
+
+	want, err := os.Stat(wantP)
+	if err != nil {
+		t.Fatalf("Stat() returned error: %s", err)
+	}
+
+	if got.Mode().Perm() != want.Mode().Perm() {
+		t.Errorf("WriteFile() created a file with mode %s, want %s", got.Mode().Perm(), want.Mode().Perm())
+	}
+
+	existingP := filepath.Join(dir, "existing.scad")
+	if err := os.WriteFile(existingP, []byte("cube();"), 0600); err != nil {
+		t.Fatalf("os.WriteFile() returned error: %s", err)
+	}
+
+	if err := fsys.WriteFile("existing.scad", []byte("sphere();")); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	info, err := os.Stat(existingP)
+	if err != nil {
+		t.Fatalf("Stat() returned error: %s", err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("WriteFile() changed the mode of an existing file to %s, want %s", info.Mode().Perm(), os.FileMode(0600))
+	}
+}
+
+func TestZipFS(t *testing.T) {
+	var buf bytes.Buffer
+	zipFS := NewZipFS(&buf)
+
+	if err := zipFS.WriteFile("a/a.scad", []byte("cube();")); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	if err := zipFS.WriteFile("a/a.scad", []byte("cube();")); err == nil {
+		t.Errorf("WriteFile() of existing file returned no error")
+	}
+
+	if err := zipFS.Close(); err != nil {
+		t.Fatalf("Close() returned error: %s", err)
+	}
+
+	zipR, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() returned error: %s", err)
+	}
+
+	got := MapFS{}
+	for _, file := range zipR.File {
+		fileR, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open() returned error: %s", err)
+		}
+
+		got[file.Name], err = io.ReadAll(fileR)
+		if err != nil {
+			t.Fatalf("ReadAll() returned error: %s", err)
+		}
+	}
+
+	want := MapFS{"a/a.scad": []byte("cube();")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ZipFS got %q, want %q", got, want)
+	}
+}
+
+func TestTarFS(t *testing.T) {
+	var buf bytes.Buffer
+	tarFS := NewTarFS(&buf)
+
+	if err := tarFS.WriteFile("a/a.scad", []byte("cube();")); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	if err := tarFS.WriteFile("a/a.scad", []byte("cube();")); err == nil {
+		t.Errorf("WriteFile() of existing file returned no error")
+	}
+
+	if err := tarFS.Close(); err != nil {
+		t.Fatalf("Close() returned error: %s", err)
+	}
+
+	got := MapFS{}
+	tarR := tar.NewReader(&buf)
+	for {
+		header, err := tarR.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() returned error: %s", err)
+		}
+
+		got[header.Name], err = io.ReadAll(tarR)
+		if err != nil {
+			t.Fatalf("ReadAll() returned error: %s", err)
+		}
+	}
+
+	want := MapFS{"a/a.scad": []byte("cube();")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TarFS got %q, want %q", got, want)
+	}
+}
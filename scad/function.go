@@ -16,8 +16,9 @@ package scad
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"path"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -29,14 +30,54 @@ type Function struct {
 	// the Function will be created as its own SCAD module.
 	ModuleName string
 
-	// Name is the function name, such as "cube", "cylinder", "translate".
+	// Name is the function name, such as "cube", "cylinder", "translate". A Function
+	// with an empty Name and non-empty Children or Assignments is a group, whose
+	// Assignments and Children are written in place of the Function itself.
 	Name string
 
+	// Arguments is a slice of positional argument values in string form. They are
+	// passed before any Parameters.
+	Arguments []string
+
 	// Parameters is a map of parameter names to parameter values in string form.
 	Parameters map[string]string
 
 	// Children is a slice of Function objects that are children of the Function.
 	Children []Function
+
+	// Else is a slice of Function objects that are the children of the Function's else
+	// block, such as for an "if" Function.
+	Else []Function
+
+	// ModuleParameters is a map of module parameter names to parameter values in string
+	// form. They are declared by the module definition, using the values as defaults, and
+	// passed as arguments when the module is called. ModuleParameters are only meaningful
+	// for modules (non-empty ModuleName).
+	ModuleParameters map[string]string
+
+	// Assignments is a slice of variable assignments made at the start of the Function's
+	// scope, which is the module body for modules, or the block of Children otherwise.
+	Assignments []Assignment
+
+	// FileAssignments is a slice of variable assignments made at the top of the file
+	// containing the Function, before any module definition. The FileAssignments of all
+	// non-module descendents are made in the same file.
+	FileAssignments []Assignment
+
+	// CustomizerParameters is a slice of ModuleParameters to expose in OpenSCAD's Customizer,
+	// in order. When the module's file is written they are assigned as annotated top-level
+	// variables, which are passed to the module call at the end of the file.
+	CustomizerParameters []CustomizerParameter
+
+	// Modifier is the Modifier to prefix the Function's call with. For modules it applies
+	// to the module call, not the module definition. For groups it applies to each child.
+	Modifier Modifier
+
+	// ParameterOrder is the order to write the Parameters and ModuleParameters in, such as
+	// the declaration order of the fields they were encoded from. Parameters not in it are
+	// written after those that are, ordered by name, so a nil ParameterOrder writes all of
+	// them ordered by name.
+	ParameterOrder []string
 }
 
 // SetParameter sets the parameter with the given key to the given value. A boolean
@@ -53,23 +94,43 @@ func (fn *Function) SetParameter(key string, value string) bool {
 	return replaced
 }
 
-// parametersString returns the Function's Parameters as a string suitable
-// to use when calling the function in .scad.
-func (fn Function) parametersString() string {
-	paramKeys := make([]string, 0, len(fn.Parameters))
-	for key := range fn.Parameters {
+// SetModuleParameter sets the module parameter with the given key to the given value. A
+// boolean is returned indicating if the module parameter was replaced (already had a value).
+func (fn *Function) SetModuleParameter(key string, value string) bool {
+	if fn.ModuleParameters == nil {
+		fn.ModuleParameters = map[string]string{}
+	}
+
+	_, replaced := fn.ModuleParameters[key]
+
+	fn.ModuleParameters[key] = value
+
+	return replaced
+}
+
+// joinParameters returns the given parameters as a string of comma separated key=value
+// pairs, ordered by key.
+func joinParameters(parameters map[string]string) string {
+	paramKeys := make([]string, 0, len(parameters))
+	for key := range parameters {
 		paramKeys = append(paramKeys, key)
 	}
 	sort.Strings(paramKeys)
 
-	params := make([]string, len(fn.Parameters))
+	params := make([]string, len(parameters))
 	for i, key := range paramKeys {
-		params[i] = fmt.Sprintf("%s=%s", key, fn.Parameters[key])
+		params[i] = fmt.Sprintf("%s=%s", key, parameters[key])
 	}
 
 	return strings.Join(params, ", ")
 }
 
+// parametersString returns the Function's Parameters as a string suitable
+// to use when calling the function in .scad.
+func (fn Function) parametersString() string {
+	return joinParameters(fn.Parameters)
+}
+
 // moduleFilename returns the module filename.
 func (fn Function) moduleFilename() string {
 	return fmt.Sprintf("%s.scad", fn.ModuleName)
@@ -91,28 +152,79 @@ func (fn Function) moduleUseString() string {
 func (fn Function) childModules() []Function {
 	var modules []Function
 
-	for _, child := range fn.Children {
-		if child.ModuleName != "" {
-			modules = append(modules, child)
-		} else {
-			modules = append(modules, child.childModules()...)
+	Inspect(fn, func(c Cursor) bool {
+		if c.Parent != nil && c.Function.ModuleName != "" {
+			modules = append(modules, c.Function)
+
+			return false
 		}
-	}
+
+		return true
+	})
 
 	return modules
 }
 
-// uniqueChildModules returns the deduplicated output of childModules.
+// moduleDefinition returns a copy of the Function with its Modifier and the values of its
+// ModuleParameters cleared. Two modules with equal definitions differ only by how they are
+// called, and can share a single module file.
+func (fn Function) moduleDefinition() Function {
+	fn.Modifier = ""
+
+	if fn.ModuleParameters == nil {
+		return fn
+	}
+
+	definitionParameters := make(map[string]string, len(fn.ModuleParameters))
+	for key := range fn.ModuleParameters {
+		definitionParameters[key] = ""
+	}
+	fn.ModuleParameters = definitionParameters
+
+	return fn
+}
+
+// uniqueChildModules returns the deduplicated output of childModules. Modules are
+// considered duplicates if their definitions are equal, in which case the first one
+// found is kept.
 func (fn Function) uniqueChildModules() ([]Function, error) {
+	return uniqueModules(fn.childModules(), false)
+}
+
+// allModules returns the deduplicated modules of the Function's entire tree, including
+// the Function itself if it is a module, and the modules nested within other modules.
+func (fn Function) allModules() ([]Function, error) {
+	if fn.ModuleName != "" {
+		return uniqueModules([]Function{fn}, true)
+	}
+
+	return uniqueModules(fn.childModules(), true)
+}
+
+// uniqueModules returns the given modules deduplicated and sorted by name, optionally
+// including the modules nested within them, which are found breadth-first. Of duplicate
+// modules the first one found is kept. An error is returned if there are multiple modules
+// with the same name but different definitions.
+func uniqueModules(modules []Function, nested bool) ([]Function, error) {
 	seenModules := map[string]Function{}
 
-	for _, module := range fn.childModules() {
+	for len(modules) > 0 {
+		module := modules[0]
+		modules = modules[1:]
+
 		if seenModule, ok := seenModules[module.ModuleName]; ok {
-			if !reflect.DeepEqual(module, seenModule) {
+			if !reflect.DeepEqual(module.moduleDefinition(), seenModule.moduleDefinition()) {
 				return nil, fmt.Errorf("conflicting module name: %s", seenModule.ModuleName)
 			}
-		} else {
-			seenModules[module.ModuleName] = module
+
+			continue
+		}
+
+		seenModules[module.ModuleName] = module
+
+		// identical definitions have identical nested modules, so only the first needs to be walked
+		if nested {
+			modules = append(modules, module.childModules()...)
 		}
 	}
 
@@ -122,12 +234,56 @@ func (fn Function) uniqueChildModules() ([]Function, error) {
 	}
 	sort.Strings(seenModuleNames)
 
-	modules := make([]Function, len(seenModuleNames))
+	unique := make([]Function, len(seenModuleNames))
 	for i, moduleName := range seenModuleNames {
-		modules[i] = seenModules[moduleName]
+		unique[i] = seenModules[moduleName]
 	}
 
-	return modules, nil
+	return unique, nil
+}
+
+// fileAssignments returns the FileAssignments of the given Functions and all of their
+// descendents that are not below a module, in order. Identical assignments are only
+// returned once, and an error is returned if the same variable is assigned different values.
+func fileAssignments(fns ...Function) ([]Assignment, error) {
+	var assignments []Assignment
+	seenValues := map[string]string{}
+
+	var collect func(Function) error
+	collect = func(collectFn Function) error {
+		for _, assignment := range collectFn.FileAssignments {
+			if seenValue, ok := seenValues[assignment.Name]; ok {
+				if seenValue != assignment.Value {
+					return fmt.Errorf("conflicting file assignment: %s", assignment.Name)
+				}
+
+				continue
+			}
+
+			seenValues[assignment.Name] = assignment.Value
+			assignments = append(assignments, assignment)
+		}
+
+		for _, child := range collectFn.allChildren() {
+			if child.ModuleName != "" {
+				continue
+			}
+
+			if err := collect(child); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, fn := range fns {
+		if err := collect(fn); err != nil {
+			return nil, err
+		}
+	}
+
+	return assignments, nil
 }
 
 // childUseStrings returns a slice of content strings containing the "use" directives
@@ -147,134 +303,111 @@ func (fn Function) childUseStrings() ([]string, error) {
 	return chUseStrings, nil
 }
 
-// moduleCallString returns the directive to call the module.
-func (fn Function) moduleCallString() string {
-	return fmt.Sprintf("%s();", fn.ModuleName)
+// moduleSelfCallString returns the directive to call the module at the end of its own file,
+// relying on the declared defaults for any parameters other than those exposed to the
+// Customizer.
+func (fn Function) moduleSelfCallString() string {
+	return fmt.Sprintf("%s(%s);", fn.ModuleName, fn.customizerArgumentsString())
 }
 
-// moduleContentStrings returns the content of a module, surrounding the function's
-// content by the module() { } syntax.
-func (fn Function) moduleContentStrings() []string {
-	var mdStrings []string
-
-	mdStrings = append(mdStrings, fmt.Sprintf("module %s() {", fn.ModuleName))
-	for _, fnString := range fn.functionCallStrings() {
-		mdStrings = append(mdStrings, fmt.Sprintf("  %s", fnString))
+// allChildren returns the Function's Children followed by its Else children.
+func (fn Function) allChildren() []Function {
+	if len(fn.Else) == 0 {
+		return fn.Children
 	}
-	mdStrings = append(mdStrings, "}")
 
-	// add module call at the end so any module can be opened directly in OpenSCAD
-	mdStrings = append(mdStrings, fn.moduleCallString())
+	children := make([]Function, 0, len(fn.Children)+len(fn.Else))
+	children = append(children, fn.Children...)
 
-	return mdStrings
+	return append(children, fn.Else...)
 }
 
-// functionCallStrings returns the content of a Function, including its children content.
-func (fn Function) functionCallStrings() []string {
-	var fnClose string = ";"
-	var childrenClose []string
-	if len(fn.Children) > 0 {
-		fnClose = " {"
-		childrenClose = []string{"}"}
-	}
+// isGroup returns a boolean indicating if the Function is a group, having no Name of its
+// own but having Children or Assignments.
+func (fn Function) isGroup() bool {
+	return fn.Name == "" && (len(fn.Children) > 0 || len(fn.Assignments) > 0)
+}
 
-	fnCallStrings := []string{
-		fmt.Sprintf("%s(%s)%s", fn.Name, fn.parametersString(), fnClose),
-	}
+// Write writes the module at the given path. Any nested modules will be written
+// at paths relative to this one. Files are written atomically, unchanged files are
+// left untouched, and a manifest of the written files is maintained as described by
+// Writer. For a Function to be successfully able to Write, it must be a module
+// (non-empty ModuleName).
+func (fn Function) Write(p string) error {
+	return fn.WriteFS(DirFS(p), "")
+}
 
-	fnStrings := []string{}
-	fnStrings = append(fnStrings, fnCallStrings...)
+// WriteFS writes the module to the given FS, in the directory p, which may be empty to
+// write to the root of the FS. Any nested modules will be written at paths relative to
+// this one, and a manifest of the written files is maintained as described by Writer. For
+// a Function to be successfully able to WriteFS, it must be a module (non-empty ModuleName).
+func (fn Function) WriteFS(fsys FS, p string) error {
+	return Writer{FS: fsys}.WriteFunction(p, fn)
+}
 
-	for _, child := range fn.Children {
-		for _, childString := range child.callStrings() {
-			fnStrings = append(fnStrings, fmt.Sprintf("  %s", childString))
-		}
-	}
-	fnStrings = append(fnStrings, childrenClose...)
+// moduleFile is a file to be written for a module.
+type moduleFile struct {
+	// name is the slash-separated path of the file.
+	name string
 
-	return fnStrings
-}
+	content []byte
 
-// callStrings returns the content for a Function, which will either be calling the module
-// by name (if ModuleName is set) or the Function's call contents.
-func (fn Function) callStrings() []string {
-	if fn.ModuleName != "" {
-		return []string{fn.moduleCallString()}
-	}
+	// module is the module the file defines.
+	module Function
 
-	return fn.functionCallStrings()
+	// uses is the slash-separated paths of the files of the modules the file uses.
+	uses []string
 }
 
-// fileContentStrings returns all content lines for the Function that are needed
-// when writing it to a file. This will include the "use" directives at the top,
-// the module content, and calling the module at the end (so any module file can
-// be opened in OpenSCAD and viewed properly on its own).
-func (fn Function) fileContentStrings() ([]string, error) {
-	fStrings := []string{}
+// moduleFiles returns the files for the module in the directory p, which are its own file
+// and those of its nested modules, at paths relative to it, written in the given Format.
+func (fn Function) moduleFiles(p string, format Format) ([]moduleFile, error) {
+	if fn.ModuleName == "" {
+		return nil, fmt.Errorf("attempted Write on non-Module")
+	}
 
-	chUseStrings, err := fn.childUseStrings()
+	content, err := format.Content(fn)
 	if err != nil {
 		return nil, err
 	}
-	fStrings = append(fStrings, chUseStrings...)
-
-	if fn.ModuleName == "" {
-		fStrings = append(fStrings, fn.functionCallStrings()...)
-	} else {
-		fStrings = append(fStrings, fn.moduleContentStrings()...)
-	}
-
-	// trailing newline
-	fStrings = append(fStrings, "")
 
-	return fStrings, nil
-}
+	files := []moduleFile{{name: path.Join(p, fn.moduleFilename()), content: []byte(content), module: fn}}
 
-// content returns the Function's content as one large string, suitable to be
-// written to a file.
-func (fn Function) content() (string, error) {
-	contentStrings, err := fn.fileContentStrings()
+	childModules, err := fn.uniqueChildModules()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return strings.Join(contentStrings, "\n"), nil
-}
+	for _, childModule := range childModules {
+		childFiles, err := childModule.moduleFiles(path.Join(p, childModule.ModuleName), format)
+		if err != nil {
+			return nil, err
+		}
 
-// Write writes the module at the given path. Any nested modules will be written
-// at paths relative to this one. For a Function to be successfully able to Write,
-// it must be a module (non-empty ModuleName).
-func (fn Function) Write(p string) error {
-	if fn.ModuleName == "" {
-		return fmt.Errorf("attempted Write on non-Module")
+		// the child module's own file is always first
+		files[0].uses = append(files[0].uses, childFiles[0].name)
+		files = append(files, childFiles...)
 	}
 
-	content, err := fn.content()
-	if err != nil {
-		return err
-	}
+	return files, nil
+}
 
-	if err := os.MkdirAll(p, 0777); err != nil {
-		return err
-	}
+// WriteFlat writes the Function and the definitions of all of its modules to a single
+// self-contained file at the given path. Unlike Write, the Function need not be a module.
+// The file is written atomically, and left untouched if unchanged, as by DirFS.
+func (fn Function) WriteFlat(p string) error {
+	return Writer{FS: DirFS(filepath.Dir(p))}.WriteFlat(filepath.Base(p), fn)
+}
 
-	writeP := path.Join(p, fn.moduleFilename())
-	writeF, err := os.OpenFile(writeP, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+// WriteTo writes the Function and the definitions of all of its modules to w as a single
+// self-contained file, the same as WriteFlat. It returns the number of bytes written.
+func (fn Function) WriteTo(w io.Writer) (int64, error) {
+	content, err := Format{}.FlatContent(fn)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if _, err := writeF.Write([]byte(content)); err != nil {
-		return err
-	}
-
-	for _, childModule := range fn.childModules() {
-		childPath := path.Join(p, childModule.ModuleName)
-
-		if err := childModule.Write(childPath); err != nil {
-			return err
-		}
-	}
+	n, err := io.WriteString(w, content)
 
-	return nil
+	return int64(n), err
 }
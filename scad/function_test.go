@@ -15,10 +15,25 @@
 package scad
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
+// contentLines returns the lines of content returned by a Format, including a final empty
+// line for its trailing newline, or nil if err isn't nil.
+func contentLines(content string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(content, "\n"), nil
+}
+
 func TestFunction_parametersString(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -53,7 +68,7 @@ func TestFunction_parametersString(t *testing.T) {
 	}
 }
 
-func TestFunction_callStrings(t *testing.T) {
+func TestFunction_contentCalls(t *testing.T) {
 	tests := []struct {
 		name  string
 		input Function
@@ -110,25 +125,193 @@ func TestFunction_callStrings(t *testing.T) {
 				"}",
 			},
 		},
+		{
+			name: "arguments and params",
+			input: Function{
+				Name:      "cube",
+				Arguments: []string{"10"},
+				Parameters: map[string]string{
+					"center": "true",
+				},
+			},
+			want: []string{
+				"cube(10, center=true);",
+			},
+		},
+		{
+			name: "assignments and children",
+			input: Function{
+				Name:        "translate",
+				Arguments:   []string{"[1, 2, 3]"},
+				Assignments: []Assignment{{Name: "size", Value: "10"}},
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"size"}},
+				},
+			},
+			want: []string{
+				"translate([1, 2, 3]) {",
+				"  size = 10;",
+				"  cube(size);",
+				"}",
+			},
+		},
+		{
+			name: "modifier",
+			input: Function{
+				Name:     "translate",
+				Modifier: Highlight,
+				Children: []Function{
+					{Name: "cube", Modifier: Background},
+				},
+			},
+			want: []string{
+				"#translate() {",
+				"  %cube();",
+				"}",
+			},
+		},
+		{
+			name: "group modifier",
+			input: Function{
+				Modifier:    Disable,
+				Assignments: []Assignment{{Name: "size", Value: "10"}},
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"size"}},
+					{Name: "sphere", Arguments: []string{"size"}, Modifier: Highlight},
+				},
+			},
+			want: []string{
+				"size = 10;",
+				"*cube(size);",
+				"*#sphere(size);",
+			},
+		},
+		{
+			name: "group",
+			input: Function{
+				Assignments: []Assignment{{Name: "size", Value: "10"}},
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"size"}},
+					{Name: "sphere", Arguments: []string{"size"}},
+				},
+			},
+			want: []string{
+				"size = 10;",
+				"cube(size);",
+				"sphere(size);",
+			},
+		},
+		{
+			name: "else",
+			input: Function{
+				Name:      "if",
+				Arguments: []string{"round"},
+				Children:  []Function{{Name: "sphere"}},
+				Else:      []Function{{Name: "cube"}},
+			},
+			want: []string{
+				"if(round) {",
+				"  sphere();",
+				"} else {",
+				"  cube();",
+				"}",
+			},
+		},
+		{
+			name: "else only",
+			input: Function{
+				Name:      "if",
+				Arguments: []string{"round"},
+				Else:      []Function{{Name: "cube"}},
+			},
+			want: []string{
+				"if(round) {",
+				"} else {",
+				"  cube();",
+				"}",
+			},
+		},
 	}
 
 	for _, test := range tests {
-		got := test.input.functionCallStrings()
+		got, err := contentLines(Format{}.Content(test.input))
+		if err != nil {
+			t.Errorf("%q Content() returned error: %s", test.name, err)
+		}
 
-		if !reflect.DeepEqual(got, test.want) {
-			t.Errorf("%q contentStrings() got\n%#v, want\n%#v", test.name, got, test.want)
+		// the content ends with a newline
+		want := append(test.want, "")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q Content() got\n%#v, want\n%#v", test.name, got, want)
+		}
+	}
+}
+
+func TestFunction_contentModuleCalls(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Function
+		want  []string
+	}{
+		{
+			name: "no module parameters",
+			input: Function{
+				ModuleName: "testModule",
+				Name:       "cube",
+			},
+			want: []string{
+				"testModule();",
+			},
+		},
+		{
+			name: "module parameters",
+			input: Function{
+				ModuleName: "testModule",
+				Name:       "cube",
+				ModuleParameters: map[string]string{
+					"size":   "10",
+					"center": "true",
+				},
+			},
+			want: []string{
+				"testModule(center=true, size=10);",
+			},
+		},
+		{
+			name: "modifier",
+			input: Function{
+				ModuleName: "testModule",
+				Name:       "cube",
+				Modifier:   Root,
+			},
+			want: []string{
+				"!testModule();",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// the module is called from the content of a group that uses it
+		got, err := contentLines(Format{}.Content(Function{Children: []Function{test.input}}))
+		if err != nil {
+			t.Errorf("%q Content() returned error: %s", test.name, err)
+		}
+
+		want := append([]string{"use <testModule/testModule.scad>"}, append(test.want, "")...)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q Content() got\n%#v, want\n%#v", test.name, got, want)
 		}
 	}
 }
 
-func TestFunction_moduleContentStrings(t *testing.T) {
+func TestFunction_contentModules(t *testing.T) {
 	tests := []struct {
 		name  string
 		input Function
 		want  []string
 	}{
 		{
-			// the empty Function's moduleContentStrings is nonsense, but test it for consistency
+			// the empty Function's module content is nonsense, but test it for consistency
 			name:  "empty",
 			input: Function{},
 			want: []string{
@@ -151,13 +334,109 @@ func TestFunction_moduleContentStrings(t *testing.T) {
 				"testModule();",
 			},
 		},
+		{
+			name: "module parameters",
+			input: Function{
+				ModuleName: "testModule",
+				Name:       "cube",
+				Parameters: map[string]string{
+					"size": "size",
+				},
+				ModuleParameters: map[string]string{
+					"size":   "10",
+					"center": "true",
+				},
+			},
+			want: []string{
+				"module testModule(center=true, size=10) {",
+				"  cube(size=size);",
+				"}",
+				"testModule();",
+			},
+		},
+		{
+			name: "modifier belongs to calls",
+			input: Function{
+				ModuleName: "testModule",
+				Name:       "cube",
+				Modifier:   Highlight,
+			},
+			want: []string{
+				"module testModule() {",
+				"  cube();",
+				"}",
+				"testModule();",
+			},
+		},
+		{
+			name: "module assignments",
+			input: Function{
+				ModuleName:  "testModule",
+				Name:        "cube",
+				Arguments:   []string{"size"},
+				Assignments: []Assignment{{Name: "size", Value: "10"}},
+			},
+			want: []string{
+				"module testModule() {",
+				"  size = 10;",
+				"  cube(size);",
+				"}",
+				"testModule();",
+			},
+		},
+		{
+			name: "group module",
+			input: Function{
+				ModuleName:  "testModule",
+				Assignments: []Assignment{{Name: "size", Value: "10"}},
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"size"}},
+					{Name: "sphere", Arguments: []string{"size"}},
+				},
+			},
+			want: []string{
+				"module testModule() {",
+				"  size = 10;",
+				"  cube(size);",
+				"  sphere(size);",
+				"}",
+				"testModule();",
+			},
+		},
+		{
+			name: "customizer parameters",
+			input: Function{
+				ModuleName: "testModule",
+				Name:       "cube",
+				Parameters: map[string]string{"size": "size"},
+				ModuleParameters: map[string]string{
+					"size":   "10",
+					"center": "true",
+				},
+				CustomizerParameters: []CustomizerParameter{
+					{Name: "size"},
+					{Name: "unset"},
+				},
+			},
+			want: []string{
+				"module testModule(center=true, size=10) {",
+				"  cube(size=size);",
+				"}",
+				"testModule(size=size);",
+			},
+		},
 	}
 
 	for _, test := range tests {
-		got := test.input.moduleContentStrings()
+		// a module's content is its definition followed by a call to it
+		var e emitter
+		test.input.emitModuleDefinition(&e)
+		e.line(test.input.moduleSelfCallString())
+
+		got := strings.Split(strings.TrimSuffix(e.buf.String(), "\n"), "\n")
 
 		if !reflect.DeepEqual(got, test.want) {
-			t.Errorf("%q moduleContentStrings() got\n%#v, want\n%#v", test.name, got, test.want)
+			t.Errorf("%q module content got\n%#v, want\n%#v", test.name, got, test.want)
 		}
 	}
 }
@@ -192,6 +471,18 @@ func TestFunction_childModules(t *testing.T) {
 				{ModuleName: "child2Module"},
 			},
 		},
+		{
+			name: "else modules",
+			input: Function{
+				Name:     "if",
+				Children: []Function{{ModuleName: "child1Module"}},
+				Else:     []Function{{ModuleName: "child2Module"}},
+			},
+			want: []Function{
+				{ModuleName: "child1Module"},
+				{ModuleName: "child2Module"},
+			},
+		},
 		{
 			name: "child modules but under a module",
 			input: Function{
@@ -309,6 +600,40 @@ func TestFunction_childUseStrings(t *testing.T) {
 				"use <child1AModule/child1AModule.scad>",
 			},
 		},
+		{
+			name: "module parameters differing only by value",
+			input: Function{
+				Children: []Function{
+					{ModuleName: "my_module", Name: "cube", ModuleParameters: map[string]string{"size": "10"}},
+					{ModuleName: "my_module", Name: "cube", ModuleParameters: map[string]string{"size": "20"}},
+				},
+			},
+			want: []string{
+				"use <my_module/my_module.scad>",
+			},
+		},
+		{
+			name: "modules differing only by modifier",
+			input: Function{
+				Children: []Function{
+					{ModuleName: "my_module", Name: "cube"},
+					{ModuleName: "my_module", Name: "cube", Modifier: Highlight},
+				},
+			},
+			want: []string{
+				"use <my_module/my_module.scad>",
+			},
+		},
+		{
+			name: "module parameters conflicts",
+			input: Function{
+				Children: []Function{
+					{ModuleName: "my_module", Name: "cube", ModuleParameters: map[string]string{"size": "10"}},
+					{ModuleName: "my_module", Name: "cube", ModuleParameters: map[string]string{"width": "10"}},
+				},
+			},
+			wantError: true,
+		},
 		{
 			name: "module name conflicts",
 			input: Function{
@@ -338,47 +663,419 @@ func TestFunction_childUseStrings(t *testing.T) {
 	}
 }
 
-type testParameterValueGetter struct {
-	value    string
-	explicit bool
-}
-
-func (testGetter testParameterValueGetter) GetParameterValue() (string, bool) {
-	return testGetter.value, testGetter.explicit
-}
-
-type testFunction struct {
-	Length testParameterValueGetter `scad:"x"`
-	Center testParameterValueGetter
-
-	cube AutoFunctionName //nolint:golint,structcheck,unused
-}
-
-func Test_EncodeFunction(t *testing.T) {
+func TestFunction_content(t *testing.T) {
 	tests := []struct {
-		name         string
-		input        interface{}
-		wantFunction Function
-		wantError    bool
+		name      string
+		input     Function
+		want      []string
+		wantError bool
 	}{
 		{
-			name:      "nil",
-			wantError: true,
+			name: "file assignments",
+			input: Function{
+				ModuleName:      "testModule",
+				Name:            "cube",
+				Arguments:       []string{"wall"},
+				FileAssignments: []Assignment{{Name: "$fn", Value: "64"}, {Name: "wall", Value: "2"}},
+			},
+			want: []string{
+				"$fn = 64;",
+				"wall = 2;",
+				"module testModule() {",
+				"  cube(wall);",
+				"}",
+				"testModule();",
+				"",
+			},
 		},
 		{
-			name:      "empty struct",
-			input:     struct{}{},
-			wantError: true,
+			name: "descendent file assignments",
+			input: Function{
+				Name:            "union",
+				FileAssignments: []Assignment{{Name: "$fn", Value: "64"}},
+				Children: []Function{
+					{
+						Name:            "sphere",
+						FileAssignments: []Assignment{{Name: "$fn", Value: "64"}, {Name: "$fa", Value: "1"}},
+					},
+					{
+						ModuleName:      "childModule",
+						Name:            "cube",
+						FileAssignments: []Assignment{{Name: "$fn", Value: "16"}},
+					},
+				},
+			},
+			want: []string{
+				"use <childModule/childModule.scad>",
+				"$fn = 64;",
+				"$fa = 1;",
+				"union() {",
+				"  sphere();",
+				"  childModule();",
+				"}",
+				"",
+			},
 		},
 		{
-			name: "untagged module name",
-			input: struct {
-				myModule   ModuleName
-				myFunction AutoFunctionName
-			}{},
-			wantFunction: Function{
-				ModuleName: "mymodule",
-				Name:       "myfunction",
+			name: "customizer parameters",
+			input: Function{
+				ModuleName: "testModule",
+				Name:       "cube",
+				Parameters: map[string]string{"center": "center", "size": "size"},
+				ModuleParameters: map[string]string{
+					"center": "true",
+					"label":  `"top"`,
+					"size":   "10",
+				},
+				CustomizerParameters: []CustomizerParameter{
+					{Name: "size", Group: "Dimensions", Widget: "[1:1:20]", Description: "Edge length"},
+					{Name: "label", Widget: "[top:Top, bottom:Bottom]"},
+					{Name: "center", Group: "Placement"},
+					{Name: "unset", Group: "Unset"},
+				},
+				FileAssignments: []Assignment{{Name: "$fn", Value: "64"}},
+			},
+			want: []string{
+				`label = "top"; // [top:Top, bottom:Bottom]`,
+				"/* [Dimensions] */",
+				"// Edge length",
+				"size = 10; // [1:1:20]",
+				"/* [Placement] */",
+				"center = true;",
+				"/* [Hidden] */",
+				"$fn = 64;",
+				"module testModule(center=true, label=\"top\", size=10) {",
+				"  cube(center=center, size=size);",
+				"}",
+				"testModule(center=center, label=label, size=size);",
+				"",
+			},
+		},
+		{
+			name: "conflicting file assignments",
+			input: Function{
+				Name:            "union",
+				FileAssignments: []Assignment{{Name: "$fn", Value: "64"}},
+				Children: []Function{
+					{
+						Name:            "sphere",
+						FileAssignments: []Assignment{{Name: "$fn", Value: "16"}},
+					},
+				},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := contentLines(Format{}.Content(test.input))
+		gotError := err != nil
+
+		if gotError != test.wantError {
+			t.Errorf("%q Content() returned error? %v (%s)", test.name, gotError, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q Content() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFunction_flatContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     Function
+		want      []string
+		wantError bool
+	}{
+		{
+			name:  "function",
+			input: Function{Name: "cube"},
+			want: []string{
+				"cube();",
+				"",
+			},
+		},
+		{
+			name: "nested modules",
+			input: Function{
+				ModuleName: "top",
+				Name:       "union",
+				Children: []Function{
+					{
+						ModuleName:      "b_module",
+						Name:            "union",
+						FileAssignments: []Assignment{{Name: "$fn", Value: "64"}},
+						Children: []Function{
+							{ModuleName: "a_module", Name: "cube", ModuleParameters: map[string]string{"size": "1"}},
+						},
+					},
+					{ModuleName: "a_module", Name: "cube", ModuleParameters: map[string]string{"size": "2"}},
+				},
+			},
+			want: []string{
+				"$fn = 64;",
+				"module a_module(size=2) {",
+				"  cube();",
+				"}",
+				"module b_module() {",
+				"  union() {",
+				"    a_module(size=1);",
+				"  }",
+				"}",
+				"module top() {",
+				"  union() {",
+				"    b_module();",
+				"    a_module(size=2);",
+				"  }",
+				"}",
+				"top();",
+				"",
+			},
+		},
+		{
+			name: "function with modules",
+			input: Function{
+				Name:            "union",
+				FileAssignments: []Assignment{{Name: "$fa", Value: "1"}},
+				Children: []Function{
+					{ModuleName: "a_module", Name: "cube", FileAssignments: []Assignment{{Name: "$fn", Value: "64"}}},
+				},
+			},
+			want: []string{
+				"$fa = 1;",
+				"$fn = 64;",
+				"module a_module() {",
+				"  cube();",
+				"}",
+				"union() {",
+				"  a_module();",
+				"}",
+				"",
+			},
+		},
+		{
+			name: "conflicting nested modules",
+			input: Function{
+				ModuleName: "top",
+				Name:       "union",
+				Children: []Function{
+					{
+						ModuleName: "b_module",
+						Name:       "union",
+						Children:   []Function{{ModuleName: "a_module", Name: "cube"}},
+					},
+					{ModuleName: "a_module", Name: "sphere"},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "conflicting module file assignments",
+			input: Function{
+				Name: "union",
+				Children: []Function{
+					{ModuleName: "a_module", Name: "cube", FileAssignments: []Assignment{{Name: "$fn", Value: "64"}}},
+					{ModuleName: "b_module", Name: "cube", FileAssignments: []Assignment{{Name: "$fn", Value: "16"}}},
+				},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := contentLines(Format{}.FlatContent(test.input))
+		gotError := err != nil
+
+		if gotError != test.wantError {
+			t.Errorf("%q FlatContent() returned error? %v (%s)", test.name, gotError, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q FlatContent() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFunction_WriteFS(t *testing.T) {
+	fn := Function{
+		ModuleName: "top",
+		Name:       "union",
+		Children: []Function{
+			{
+				ModuleName: "child",
+				Name:       "union",
+				Children:   []Function{{ModuleName: "grandchild", Name: "cube"}},
+			},
+		},
+	}
+
+	got := MapFS{}
+	if err := fn.WriteFS(got, "out"); err != nil {
+		t.Fatalf("WriteFS() returned error: %s", err)
+	}
+
+	// the manifest is covered by the Writer tests
+	if _, ok := got["out/"+ManifestName]; !ok {
+		t.Errorf("WriteFS() didn't write a manifest")
+	}
+	delete(got, "out/"+ManifestName)
+
+	want := MapFS{
+		"out/top.scad":                         []byte("use <child/child.scad>\nmodule top() {\n  union() {\n    child();\n  }\n}\ntop();\n"),
+		"out/child/child.scad":                 []byte("use <grandchild/grandchild.scad>\nmodule child() {\n  union() {\n    grandchild();\n  }\n}\nchild();\n"),
+		"out/child/grandchild/grandchild.scad": []byte("module grandchild() {\n  cube();\n}\ngrandchild();\n"),
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteFS() got\n%q, want\n%q", got, want)
+	}
+
+	if err := (Function{Name: "cube"}).WriteFS(MapFS{}, ""); err == nil {
+		t.Errorf("WriteFS() of non-module returned no error")
+	}
+}
+
+func TestFunction_WriteFlat(t *testing.T) {
+	fn := Function{
+		Name:     "union",
+		Children: []Function{{ModuleName: "child", Name: "cube"}},
+	}
+
+	dir := t.TempDir()
+	p := filepath.Join(dir, "out", "flat.scad")
+	if err := fn.WriteFlat(p); err != nil {
+		t.Fatalf("WriteFlat() returned error: %s", err)
+	}
+
+	got, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %s", err)
+	}
+
+	want := "module child() {\n  cube();\n}\nunion() {\n  child();\n}\n"
+	if string(got) != want {
+		t.Errorf("WriteFlat() wrote %q, want %q", got, want)
+	}
+}
+
+func TestFunction_WriteTo(t *testing.T) {
+	fn := Function{
+		Name:     "union",
+		Children: []Function{{ModuleName: "child", Name: "cube"}},
+	}
+
+	var buf bytes.Buffer
+	n, err := fn.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() returned error: %s", err)
+	}
+
+	want := "module child() {\n  cube();\n}\nunion() {\n  child();\n}\n"
+	if buf.String() != want {
+		t.Errorf("WriteTo() wrote %q, want %q", buf.String(), want)
+	}
+
+	if n != int64(len(want)) {
+		t.Errorf("WriteTo() returned %d, want %d", n, len(want))
+	}
+}
+
+type testParameterValueGetter struct {
+	value    string
+	explicit bool
+}
+
+func (testGetter testParameterValueGetter) GetParameterValue() (string, bool) {
+	return testGetter.value, testGetter.explicit
+}
+
+type testFunction struct {
+	Length testParameterValueGetter `scad:"x"`
+	Center testParameterValueGetter
+
+	cube AutoFunctionName //nolint:golint,structcheck,unused
+}
+
+// testModuleEncoder encodes its Child in its place.
+type testModuleEncoder struct {
+	Child interface{} `scad:"-"`
+}
+
+func (v testModuleEncoder) EncodeSCAD() (interface{}, error) {
+	return v.Child, nil
+}
+
+// testNamedModuleEncoder encodes its Child in its place, as the module of its own.
+type testNamedModuleEncoder struct {
+	myModule ModuleName `scad:"outer_module"` //nolint:golint,structcheck,unused
+
+	Width int         `scad:"width,parameter"`
+	Child interface{} `scad:"-"`
+}
+
+func (v testNamedModuleEncoder) EncodeSCAD() (interface{}, error) {
+	return v.Child, nil
+}
+
+type TestResolution struct {
+	FA testParameterValueGetter `scad:"$fa"`
+	FS testParameterValueGetter `scad:"$fs"`
+	FN testParameterValueGetter `scad:"$fn"`
+}
+
+func Test_EncodeFunction(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        interface{}
+		wantFunction Function
+		wantError    bool
+	}{
+		{
+			name:      "nil",
+			wantError: true,
+		},
+		{
+			name: "Function",
+			input: Function{
+				Name:      "cube",
+				Arguments: []string{"10"},
+			},
+			wantFunction: Function{
+				Name:      "cube",
+				Arguments: []string{"10"},
+			},
+		},
+		{
+			name: "Function pointer",
+			input: &Function{
+				Name:      "cube",
+				Arguments: []string{"10"},
+			},
+			wantFunction: Function{
+				Name:      "cube",
+				Arguments: []string{"10"},
+			},
+		},
+		{
+			name:      "nil struct pointer",
+			input:     (*testFunction)(nil),
+			wantError: true,
+		},
+		{
+			name:      "empty struct",
+			input:     struct{}{},
+			wantError: true,
+		},
+		{
+			name: "untagged module name",
+			input: struct {
+				myModule   ModuleName
+				myFunction AutoFunctionName
+			}{},
+			wantFunction: Function{
+				ModuleName: "mymodule",
+				Name:       "myfunction",
 			},
 		},
 		{
@@ -506,6 +1203,289 @@ func Test_EncodeFunction(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "module parameters",
+			input: struct {
+				myModule ModuleName `scad:"my_module"`
+				cube     AutoFunctionName
+				Length   testParameterValueGetter `scad:"x,parameter"`
+				Width    float64                  `scad:",parameter"`
+				Center   testParameterValueGetter
+			}{
+				Length: testParameterValueGetter{value: "10", explicit: true},
+				Width:  2.5,
+				Center: testParameterValueGetter{value: "true", explicit: true},
+			},
+			wantFunction: Function{
+				ModuleName: "my_module",
+				Name:       "cube",
+				Parameters: map[string]string{
+					"x":      "x",
+					"center": "true",
+				},
+				ModuleParameters: map[string]string{
+					"x":     "10",
+					"width": "2.5",
+				},
+			},
+		},
+		{
+			name: "unset module parameter",
+			input: struct {
+				myModule ModuleName `scad:"my_module"`
+				cube     AutoFunctionName
+				Length   testParameterValueGetter `scad:"x,parameter"`
+			}{},
+			wantFunction: Function{
+				ModuleName: "my_module",
+				Name:       "cube",
+			},
+		},
+		{
+			name: "module parameters without module",
+			input: struct {
+				cube  AutoFunctionName
+				Width float64 `scad:",parameter"`
+			}{},
+			wantError: true,
+		},
+		{
+			name: "unsupported module parameter type",
+			input: struct {
+				myModule ModuleName `scad:"my_module"`
+				cube     AutoFunctionName
+				Width    struct{} `scad:",parameter"`
+			}{},
+			wantError: true,
+		},
+		{
+			name: "customizable module parameters",
+			input: struct {
+				myModule ModuleName `scad:"my_module"`
+				cube     AutoFunctionName
+				Length   testParameterValueGetter `scad:"x,customize,range=1:20,step=0.5,group=Dimensions" description:"Edge length"`
+				Label    string                   `scad:",customize,options=top:Top|bottom:Bottom"`
+				Width    float64                  `scad:",parameter"`
+			}{
+				Length: testParameterValueGetter{value: "10", explicit: true},
+				Label:  "top",
+				Width:  2.5,
+			},
+			wantFunction: Function{
+				ModuleName: "my_module",
+				Name:       "cube",
+				Parameters: map[string]string{
+					"x": "x",
+				},
+				ModuleParameters: map[string]string{
+					"x":     "10",
+					"label": `"top"`,
+					"width": "2.5",
+				},
+				CustomizerParameters: []CustomizerParameter{
+					{Name: "x", Description: "Edge length", Group: "Dimensions", Widget: "[1:0.5:20]"},
+					{Name: "label", Widget: "[top:Top, bottom:Bottom]"},
+				},
+			},
+		},
+		{
+			name: "customizable range and options",
+			input: struct {
+				myModule ModuleName `scad:"my_module"`
+				cube     AutoFunctionName
+				Width    float64 `scad:",customize,range=1:20,options=1|2"`
+			}{},
+			wantError: true,
+		},
+		{
+			name: "customizable step without range",
+			input: struct {
+				myModule ModuleName `scad:"my_module"`
+				cube     AutoFunctionName
+				Width    float64 `scad:",customize,step=1"`
+			}{},
+			wantError: true,
+		},
+		{
+			name: "customizable invalid range",
+			input: struct {
+				myModule ModuleName `scad:"my_module"`
+				cube     AutoFunctionName
+				Width    float64 `scad:",customize,range=20"`
+			}{},
+			wantError: true,
+		},
+		{
+			name: "ignored fields",
+			input: struct {
+				cube     AutoFunctionName
+				Length   testParameterValueGetter `scad:"-"`
+				Modifier Modifier                 `scad:"-"`
+				Children []interface{}            `scad:"-"`
+			}{
+				Length:   testParameterValueGetter{value: "10", explicit: true},
+				Modifier: Highlight,
+				Children: []interface{}{testFunction{}},
+			},
+			wantFunction: Function{
+				Name: "cube",
+			},
+		},
+		{
+			name: "modifier fields",
+			input: struct {
+				cube      AutoFunctionName
+				Modifier  Modifier
+				Stacked   Modifier
+				unchecked Modifier
+			}{
+				Modifier:  Root,
+				Stacked:   Highlight,
+				unchecked: "invalid",
+			},
+			wantFunction: Function{
+				Name:     "cube",
+				Modifier: "!#",
+			},
+		},
+		{
+			name: "invalid modifier",
+			input: struct {
+				cube     AutoFunctionName
+				Modifier Modifier
+			}{
+				Modifier: "#?",
+			},
+			wantError: true,
+		},
+		{
+			name:  "Modified",
+			input: Apply(testFunction{}, Highlight, Modified{Modifier: Disable}),
+			wantFunction: Function{
+				Name:     "cube",
+				Modifier: "*#",
+			},
+		},
+		{
+			name: "Modified module",
+			input: Modified{
+				Modifier: Background,
+				Child: struct {
+					myModule ModuleName `scad:"my_module"`
+					cube     AutoFunctionName
+				}{},
+			},
+			wantFunction: Function{
+				ModuleName: "my_module",
+				Name:       "cube",
+				Modifier:   Background,
+			},
+		},
+		{
+			name: "SCADEncoder without module keeps encoded module",
+			input: testModuleEncoder{
+				Child: struct {
+					myModule ModuleName `scad:"my_module"`
+					cube     AutoFunctionName
+				}{},
+			},
+			wantFunction: Function{
+				ModuleName: "my_module",
+				Name:       "cube",
+			},
+		},
+		{
+			name: "SCADEncoder module replaces encoded module",
+			input: testNamedModuleEncoder{
+				Width: 10,
+				Child: struct {
+					myModule ModuleName `scad:"my_module"`
+					Size     int        `scad:"size,parameter"`
+					cube     AutoFunctionName
+				}{Size: 2},
+			},
+			wantFunction: Function{
+				ModuleName:       "outer_module",
+				Name:             "cube",
+				ModuleParameters: map[string]string{"width": "10", "size": "2"},
+			},
+		},
+		{
+			name: "SCADEncoder module takes module parameters of wrapped Function",
+			input: testNamedModuleEncoder{
+				Width: 10,
+				Child: Function{
+					ModuleName:       "inner_module",
+					Name:             "cube",
+					Parameters:       map[string]string{"size": "[width, depth, 1]"},
+					ModuleParameters: map[string]string{"depth": "5"},
+				},
+			},
+			wantFunction: Function{
+				ModuleName:       "outer_module",
+				Name:             "cube",
+				Parameters:       map[string]string{"size": "[width, depth, 1]"},
+				ModuleParameters: map[string]string{"width": "10", "depth": "5"},
+			},
+		},
+		{
+			name: "SCADEncoder module parameter conflicts with encoded module",
+			input: testNamedModuleEncoder{
+				Width: 10,
+				Child: Function{
+					ModuleName:       "inner_module",
+					Name:             "cube",
+					ModuleParameters: map[string]string{"width": "20"},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "variables",
+			input: struct {
+				cube      AutoFunctionName
+				Quality   Variables `scad:",file"`
+				Constants Variables
+				unchecked Variables
+			}{
+				Quality: Variables{
+					{Name: "$fn", Value: 64},
+					{Name: "$fa", Value: testParameterValueGetter{value: "1", explicit: true}},
+					{Name: "$fs", Value: testParameterValueGetter{value: "2"}},
+					{Name: "unset"},
+				},
+				Constants: Variables{
+					{Name: "wall", Value: 2.5},
+					{Name: "label", Value: "top"},
+				},
+				unchecked: Variables{
+					{Name: "unchecked", Value: struct{}{}},
+				},
+			},
+			wantFunction: Function{
+				Name: "cube",
+				FileAssignments: []Assignment{
+					{Name: "$fn", Value: "64"},
+					{Name: "$fa", Value: "1"},
+				},
+				Assignments: []Assignment{
+					{Name: "wall", Value: "2.5"},
+					{Name: "label", Value: `"top"`},
+				},
+			},
+		},
+		{
+			name: "unsupported variable type",
+			input: struct {
+				cube      AutoFunctionName
+				Constants Variables
+			}{
+				Constants: Variables{
+					{Name: "invalid", Value: struct{}{}},
+				},
+			},
+			wantError: true,
+		},
 		{
 			name: "multiple children fields",
 			input: struct {
@@ -515,6 +1495,229 @@ func Test_EncodeFunction(t *testing.T) {
 			}{},
 			wantError: true,
 		},
+		{
+			name: "required set",
+			input: struct {
+				cylinder AutoFunctionName
+				H        testParameterValueGetter `scad:"h,required"`
+			}{
+				H: testParameterValueGetter{value: "10", explicit: true},
+			},
+			wantFunction: Function{
+				Name:       "cylinder",
+				Parameters: map[string]string{"h": "10"},
+			},
+		},
+		{
+			name: "required unset",
+			input: struct {
+				cylinder AutoFunctionName
+				H        testParameterValueGetter `scad:"h,required"`
+			}{},
+			wantError: true,
+		},
+		{
+			name: "required children unset",
+			input: struct {
+				translate AutoFunctionName
+				Children  []interface{} `scad:",required"`
+			}{
+				Children: []interface{}{},
+			},
+			wantError: true,
+		},
+		{
+			name: "oneof single set",
+			input: struct {
+				sphere AutoFunctionName
+				R      testParameterValueGetter `scad:"r,oneof=radius"`
+				D      testParameterValueGetter `scad:"d,oneof=radius"`
+			}{
+				D: testParameterValueGetter{value: "10", explicit: true},
+			},
+			wantFunction: Function{
+				Name:       "sphere",
+				Parameters: map[string]string{"d": "10"},
+			},
+		},
+		{
+			name: "oneof multiple set",
+			input: struct {
+				sphere AutoFunctionName
+				R      testParameterValueGetter `scad:"r,oneof=radius"`
+				D      testParameterValueGetter `scad:"d,oneof=radius"`
+			}{
+				R: testParameterValueGetter{value: "5", explicit: true},
+				D: testParameterValueGetter{value: "10", explicit: true},
+			},
+			wantError: true,
+		},
+		{
+			name: "min satisfied",
+			input: struct {
+				cube AutoFunctionName
+				Size testParameterValueGetter `scad:"size,min=0"`
+			}{
+				Size: testParameterValueGetter{value: "[0, 1, 2]", explicit: true},
+			},
+			wantFunction: Function{
+				Name:       "cube",
+				Parameters: map[string]string{"size": "[0, 1, 2]"},
+			},
+		},
+		{
+			name: "min violated",
+			input: struct {
+				cube AutoFunctionName
+				Size testParameterValueGetter `scad:"size,min=0"`
+			}{
+				Size: testParameterValueGetter{value: "[1, -1, 2]", explicit: true},
+			},
+			wantError: true,
+		},
+		{
+			name: "min expression unchecked",
+			input: struct {
+				cube AutoFunctionName
+				Size testParameterValueGetter `scad:"size,min=0"`
+			}{
+				Size: testParameterValueGetter{value: "-wall", explicit: true},
+			},
+			wantFunction: Function{
+				Name:       "cube",
+				Parameters: map[string]string{"size": "-wall"},
+			},
+		},
+		{
+			name: "invalid min",
+			input: struct {
+				cube AutoFunctionName
+				Size testParameterValueGetter `scad:"size,min=zero"`
+			}{
+				Size: testParameterValueGetter{value: "1", explicit: true},
+			},
+			wantError: true,
+		},
+		{
+			name: "embedded struct",
+			input: struct {
+				sphere AutoFunctionName
+				TestResolution
+				R testParameterValueGetter `scad:"r"`
+			}{
+				TestResolution: TestResolution{FN: testParameterValueGetter{value: "24", explicit: true}},
+				R:              testParameterValueGetter{value: "5", explicit: true},
+			},
+			wantFunction: Function{
+				Name:       "sphere",
+				Parameters: map[string]string{"$fn": "24", "r": "5"},
+			},
+		},
+		{
+			name: "embedded struct pointer",
+			input: struct {
+				sphere AutoFunctionName
+				*TestResolution
+			}{
+				TestResolution: &TestResolution{FA: testParameterValueGetter{value: "6", explicit: true}},
+			},
+			wantFunction: Function{
+				Name:       "sphere",
+				Parameters: map[string]string{"$fa": "6"},
+			},
+		},
+		{
+			name: "nil embedded struct pointer",
+			input: struct {
+				sphere AutoFunctionName
+				*TestResolution
+			}{},
+			wantFunction: Function{
+				Name: "sphere",
+			},
+		},
+		{
+			name: "tagged embedded struct not flattened",
+			input: struct {
+				sphere         AutoFunctionName
+				TestResolution `scad:"resolution"`
+			}{
+				TestResolution: TestResolution{FN: testParameterValueGetter{value: "24", explicit: true}},
+			},
+			wantFunction: Function{
+				Name: "sphere",
+			},
+		},
+		{
+			name: "embedded struct duplicate parameter",
+			input: struct {
+				sphere AutoFunctionName
+				TestResolution
+				FN testParameterValueGetter `scad:"$fn"`
+			}{
+				TestResolution: TestResolution{FN: testParameterValueGetter{value: "24", explicit: true}},
+				FN:             testParameterValueGetter{value: "12", explicit: true},
+			},
+			wantError: true,
+		},
+		{
+			name: "child fields",
+			input: struct {
+				difference AutoFunctionName
+				Base       interface{}   `scad:",child"`
+				Missing    interface{}   `scad:",child"`
+				Cut        *testFunction `scad:",child"`
+				Children   []interface{}
+			}{
+				Base:     Function{Name: "cube"},
+				Cut:      &testFunction{Length: testParameterValueGetter{value: "1", explicit: true}},
+				Children: []interface{}{Function{Name: "sphere"}},
+			},
+			wantFunction: Function{
+				Name: "difference",
+				Children: []Function{
+					{Name: "cube"},
+					{Name: "cube", Parameters: map[string]string{"x": "1"}},
+					{Name: "sphere"},
+				},
+			},
+		},
+		{
+			name: "required child field",
+			input: struct {
+				difference AutoFunctionName
+				Base       interface{} `scad:",child,required"`
+			}{},
+			wantError: true,
+		},
+		{
+			name: "child field with multiple slice fields",
+			input: struct {
+				difference   AutoFunctionName
+				Base         interface{} `scad:",child"`
+				Children     []interface{}
+				MoreChildren []interface{}
+			}{
+				Base: Function{Name: "cube"},
+			},
+			wantError: true,
+		},
+		{
+			name: "omitempty module parameters",
+			input: struct {
+				box    ModuleName
+				cube   AutoFunctionName
+				Width  float64 `scad:"width,parameter,omitempty"`
+				Height float64 `scad:"height,parameter,omitempty"`
+			}{
+				Width: 10,
+			},
+			wantFunction: Function{
+				ModuleName:       "box",
+				Name:             "cube",
+				ModuleParameters: map[string]string{"width": "10"},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -530,3 +1733,79 @@ func Test_EncodeFunction(t *testing.T) {
 		}
 	}
 }
+
+// testParameterizedEncoder is a module whose content uses its width module parameter, either
+// by name or by value.
+type testParameterizedEncoder struct {
+	block ModuleName `scad:"block"` //nolint:golint,structcheck,unused
+
+	Width   float64 `scad:"width,parameter"`
+	ByValue bool    `scad:"-"`
+}
+
+func (v testParameterizedEncoder) EncodeSCAD() (interface{}, error) {
+	size := "width"
+	if v.ByValue {
+		size = strconv.FormatFloat(v.Width, 'g', -1, 64)
+	}
+
+	return Function{Name: "cube", Parameters: map[string]string{"size": size}}, nil
+}
+
+func TestEncode_moduleParameterValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     interface{}
+		want      []string
+		wantError bool
+	}{
+		{
+			name: "parameters by name",
+			input: struct {
+				union    AutoFunctionName
+				Children []interface{}
+			}{
+				Children: []interface{}{
+					testParameterizedEncoder{Width: 60},
+					testParameterizedEncoder{Width: 40},
+				},
+			},
+			want: []string{
+				"module block(width=60) {",
+				"  cube(size=width);",
+				"}",
+				"union() {",
+				"  block(width=60);",
+				"  block(width=40);",
+				"}",
+				"",
+			},
+		},
+		{
+			name: "parameters by value",
+			input: struct {
+				union    AutoFunctionName
+				Children []interface{}
+			}{
+				Children: []interface{}{
+					testParameterizedEncoder{Width: 60, ByValue: true},
+					testParameterizedEncoder{Width: 40, ByValue: true},
+				},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := contentLines(FlatFunctionContent(test.input))
+		gotError := err != nil
+
+		if gotError != test.wantError {
+			t.Errorf("%q FlatFunctionContent() returned error? %v (%s)", test.name, gotError, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q FlatFunctionContent() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+	}
+}
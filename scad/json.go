@@ -0,0 +1,203 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONVersion is the version of the JSON schema written by Marshal. Unmarshal accepts
+// documents of this version and earlier. Version 2 added the Function's ParameterOrder.
+const JSONVersion = 2
+
+// jsonDocument is the top level of the JSON schema.
+type jsonDocument struct {
+	Version  int          `json:"version"`
+	Function jsonFunction `json:"function"`
+}
+
+// jsonFunction is the JSON schema of a Function. It is kept separate from Function so that
+// the schema only changes deliberately, with JSONVersion.
+type jsonFunction struct {
+	ModuleName           string                    `json:"moduleName,omitempty"`
+	Name                 string                    `json:"name,omitempty"`
+	Arguments            []string                  `json:"arguments,omitempty"`
+	Parameters           map[string]string         `json:"parameters,omitempty"`
+	Children             []jsonFunction            `json:"children,omitempty"`
+	Else                 []jsonFunction            `json:"else,omitempty"`
+	ModuleParameters     map[string]string         `json:"moduleParameters,omitempty"`
+	Assignments          []jsonAssignment          `json:"assignments,omitempty"`
+	FileAssignments      []jsonAssignment          `json:"fileAssignments,omitempty"`
+	CustomizerParameters []jsonCustomizerParameter `json:"customizerParameters,omitempty"`
+	Modifier             string                    `json:"modifier,omitempty"`
+	ParameterOrder       []string                  `json:"parameterOrder,omitempty"`
+}
+
+// jsonAssignment is the JSON schema of an Assignment.
+type jsonAssignment struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// jsonCustomizerParameter is the JSON schema of a CustomizerParameter.
+type jsonCustomizerParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Group       string `json:"group,omitempty"`
+	Widget      string `json:"widget,omitempty"`
+}
+
+// newJSONFunctions returns the jsonFunctions for a slice of Functions.
+func newJSONFunctions(fns []Function) []jsonFunction {
+	if len(fns) == 0 {
+		return nil
+	}
+
+	jsonFns := make([]jsonFunction, len(fns))
+	for i, fn := range fns {
+		jsonFns[i] = newJSONFunction(fn)
+	}
+
+	return jsonFns
+}
+
+// newJSONAssignments returns the jsonAssignments for a slice of Assignments.
+func newJSONAssignments(assignments []Assignment) []jsonAssignment {
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	jsonAssignments := make([]jsonAssignment, len(assignments))
+	for i, assignment := range assignments {
+		jsonAssignments[i] = jsonAssignment(assignment)
+	}
+
+	return jsonAssignments
+}
+
+// newJSONFunction returns the jsonFunction for a Function.
+func newJSONFunction(fn Function) jsonFunction {
+	jsonFn := jsonFunction{
+		ModuleName:       fn.ModuleName,
+		Name:             fn.Name,
+		Arguments:        fn.Arguments,
+		Parameters:       fn.Parameters,
+		Children:         newJSONFunctions(fn.Children),
+		Else:             newJSONFunctions(fn.Else),
+		ModuleParameters: fn.ModuleParameters,
+		Assignments:      newJSONAssignments(fn.Assignments),
+		FileAssignments:  newJSONAssignments(fn.FileAssignments),
+		Modifier:         string(fn.Modifier),
+		ParameterOrder:   fn.ParameterOrder,
+	}
+
+	for _, parameter := range fn.CustomizerParameters {
+		jsonFn.CustomizerParameters = append(jsonFn.CustomizerParameters, jsonCustomizerParameter(parameter))
+	}
+
+	return jsonFn
+}
+
+// jsonFunctions returns the Functions for a slice of jsonFunctions.
+func jsonFunctions(jsonFns []jsonFunction) []Function {
+	if len(jsonFns) == 0 {
+		return nil
+	}
+
+	fns := make([]Function, len(jsonFns))
+	for i, jsonFn := range jsonFns {
+		fns[i] = jsonFn.function()
+	}
+
+	return fns
+}
+
+// jsonAssignments returns the Assignments for a slice of jsonAssignments.
+func jsonAssignments(jsonAssignments []jsonAssignment) []Assignment {
+	if len(jsonAssignments) == 0 {
+		return nil
+	}
+
+	assignments := make([]Assignment, len(jsonAssignments))
+	for i, jsonAssignment := range jsonAssignments {
+		assignments[i] = Assignment(jsonAssignment)
+	}
+
+	return assignments
+}
+
+// function returns the Function for a jsonFunction.
+func (jsonFn jsonFunction) function() Function {
+	fn := Function{
+		ModuleName:       jsonFn.ModuleName,
+		Name:             jsonFn.Name,
+		Arguments:        jsonFn.Arguments,
+		Parameters:       jsonFn.Parameters,
+		Children:         jsonFunctions(jsonFn.Children),
+		Else:             jsonFunctions(jsonFn.Else),
+		ModuleParameters: jsonFn.ModuleParameters,
+		Assignments:      jsonAssignments(jsonFn.Assignments),
+		FileAssignments:  jsonAssignments(jsonFn.FileAssignments),
+		Modifier:         Modifier(jsonFn.Modifier),
+		ParameterOrder:   jsonFn.ParameterOrder,
+	}
+
+	for _, parameter := range jsonFn.CustomizerParameters {
+		fn.CustomizerParameters = append(fn.CustomizerParameters, CustomizerParameter(parameter))
+	}
+
+	return fn
+}
+
+// Marshal returns the JSON encoding of a Function tree, as a document of the current
+// JSONVersion. Empty fields are omitted.
+func Marshal(fn Function) ([]byte, error) {
+	return json.Marshal(jsonDocument{Version: JSONVersion, Function: newJSONFunction(fn)})
+}
+
+// Unmarshal parses a JSON document written by Marshal, storing the Function tree in fn. An
+// error is returned if the document's version is newer than JSONVersion, or it has unknown
+// fields.
+// Empty slices and maps are unmarshaled as nil, which has no effect on a Function's
+// content.
+func Unmarshal(data []byte, fn *Function) error {
+	// the version is checked first, so that documents of other versions aren't rejected for
+	// their unknown fields
+	var version struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return fmt.Errorf("scad: %w", err)
+	}
+
+	// each version only adds to the schema, so earlier documents are still valid
+	if version.Version < 1 || version.Version > JSONVersion {
+		return fmt.Errorf("scad: unsupported JSON version: %d", version.Version)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var document jsonDocument
+	if err := decoder.Decode(&document); err != nil {
+		return fmt.Errorf("scad: %w", err)
+	}
+
+	*fn = document.Function.function()
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"reflect"
+	"testing"
+)
+
+// jsonTestTree is a Function tree with every field set.
+var jsonTestTree = Function{
+	ModuleName:       "box",
+	Name:             "difference",
+	ModuleParameters: map[string]string{"size": "10"},
+	ParameterOrder:   []string{"size"},
+	Assignments:      []Assignment{{Name: "wall", Value: "2"}},
+	FileAssignments:  []Assignment{{Name: "$fn", Value: "24"}},
+	CustomizerParameters: []CustomizerParameter{
+		{Name: "size", Description: "Outer size", Group: "Box", Widget: "[5:20]"},
+	},
+	Children: []Function{
+		{Name: "cube", Arguments: []string{"size"}},
+		{
+			Name:      "if",
+			Arguments: []string{"size > 5"},
+			Modifier:  Highlight,
+			Children: []Function{
+				{
+					Name:       "translate",
+					Parameters: map[string]string{"v": "[wall, wall, wall]"},
+					Children:   []Function{{Name: "cube", Arguments: []string{"size - 2*wall"}}},
+				},
+			},
+			Else: []Function{{Name: "sphere", Arguments: []string{"size / 2"}}},
+		},
+	},
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Function
+		want  string
+	}{
+		{
+			name:  "empty",
+			input: Function{},
+			want:  `{"version":2,"function":{}}`,
+		},
+		{
+			name: "nested",
+			input: Function{
+				Name:     "translate",
+				Modifier: Background,
+				Arguments: []string{
+					"[0, 0, 5]",
+				},
+				Children: []Function{
+					{Name: "cube", Parameters: map[string]string{"size": "10", "center": "true"}},
+				},
+			},
+			want: `{"version":2,"function":{"name":"translate","arguments":["[0, 0, 5]"],"children":[{"name":"cube","parameters":{"center":"true","size":"10"}}],"modifier":"%"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Marshal(test.input)
+		if err != nil {
+			t.Fatalf("%q Marshal() returned error: %s", test.name, err)
+		}
+
+		if string(got) != test.want {
+			t.Errorf("%q Marshal() got\n%s, want\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      Function
+		wantError bool
+	}{
+		{
+			// documents of earlier versions are still accepted
+			name:  "version 1",
+			input: `{"version":1,"function":{"name":"cube","arguments":["10"],"else":[{"name":"sphere"}],"assignments":[{"name":"a","value":"1"}]}}`,
+			want: Function{
+				Name:        "cube",
+				Arguments:   []string{"10"},
+				Else:        []Function{{Name: "sphere"}},
+				Assignments: []Assignment{{Name: "a", Value: "1"}},
+			},
+		},
+		{
+			name:  "parameter order",
+			input: `{"version":2,"function":{"name":"cube","parameters":{"size":"10","center":"true"},"parameterOrder":["size","center"]}}`,
+			want: Function{
+				Name:           "cube",
+				Parameters:     map[string]string{"size": "10", "center": "true"},
+				ParameterOrder: []string{"size", "center"},
+			},
+		},
+		{
+			name:      "missing version",
+			input:     `{"function":{"name":"cube"}}`,
+			wantError: true,
+		},
+		{
+			name:      "unsupported version",
+			input:     `{"version":3,"function":{"name":"cube","comments":["new"]}}`,
+			wantError: true,
+		},
+		{
+			name:      "unknown field",
+			input:     `{"version":1,"function":{"name":"cube","size":10}}`,
+			wantError: true,
+		},
+		{
+			name:      "invalid",
+			input:     `{"version":1,`,
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		var got Function
+		err := Unmarshal([]byte(test.input), &got)
+		gotError := err != nil
+
+		if gotError != test.wantError {
+			t.Errorf("%q Unmarshal() returned error? %v (%s)", test.name, gotError, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q Unmarshal() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMarshal_roundTrip(t *testing.T) {
+	data, err := Marshal(jsonTestTree)
+	if err != nil {
+		t.Fatalf("Marshal() returned error: %s", err)
+	}
+
+	var got Function
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() returned error: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, jsonTestTree) {
+		t.Errorf("round trip got\n%#v, want\n%#v", got, jsonTestTree)
+	}
+
+	gotFS := MapFS{}
+	if err := got.WriteFS(gotFS, ""); err != nil {
+		t.Fatalf("WriteFS() returned error: %s", err)
+	}
+
+	wantFS := MapFS{}
+	if err := jsonTestTree.WriteFS(wantFS, ""); err != nil {
+		t.Fatalf("WriteFS() returned error: %s", err)
+	}
+
+	if !reflect.DeepEqual(gotFS, wantFS) {
+		t.Errorf("round trip wrote\n%#v, want\n%#v", gotFS, wantFS)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// ManifestName is the name of the manifest file a Writer maintains in each directory it
+// writes a Function to.
+const ManifestName = ".scad-manifest.json"
+
+// Manifest lists the files generated by a Writer.
+type Manifest struct {
+	// Files is the generated files, sorted by Path.
+	Files []ManifestFile `json:"files"`
+}
+
+// ManifestFile describes a module file generated by a Writer.
+type ManifestFile struct {
+	// Path is the slash-separated path of the file, relative to the manifest.
+	Path string `json:"path"`
+
+	// SHA256 is the hex-encoded SHA-256 hash of the file's content.
+	SHA256 string `json:"sha256"`
+
+	// Module is the name of the module defined by the file.
+	Module string `json:"module"`
+
+	// ModuleParameters is a map of the module's parameter names to their default values.
+	ModuleParameters map[string]string `json:"moduleParameters,omitempty"`
+
+	// Uses is the paths of the files of the modules used directly by the module, relative
+	// to the manifest, sorted.
+	Uses []string `json:"uses,omitempty"`
+}
+
+// newManifest returns the Manifest for the given module files.
+func newManifest(files []moduleFile) Manifest {
+	manifest := Manifest{Files: make([]ManifestFile, len(files))}
+
+	for i, file := range files {
+		hash := sha256.Sum256(file.content)
+
+		manifest.Files[i] = ManifestFile{
+			Path:             file.name,
+			SHA256:           hex.EncodeToString(hash[:]),
+			Module:           file.module.ModuleName,
+			ModuleParameters: file.module.ModuleParameters,
+			Uses:             file.uses,
+		}
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	return manifest
+}
+
+// Manifest returns the Manifest of the files that would be written for the module by a
+// Writer with the default Format. Writer's Manifest method describes the files written in
+// other Formats.
+func (fn Function) Manifest() (Manifest, error) {
+	return Writer{}.Manifest(fn)
+}
+
+// DOT returns the graph of the Manifest's modules and their use of each other in the
+// Graphviz DOT language. Each module is a node, regardless of how many files define it.
+func (manifest Manifest) DOT() string {
+	modulePaths := map[string]string{}
+	for _, file := range manifest.Files {
+		modulePaths[file.Path] = file.Module
+	}
+
+	modules := map[string]bool{}
+	edges := map[string]bool{}
+
+	for _, file := range manifest.Files {
+		modules[fmt.Sprintf("  %q;", file.Module)] = true
+
+		for _, use := range file.Uses {
+			if usedModule, ok := modulePaths[use]; ok {
+				edges[fmt.Sprintf("  %q -> %q;", file.Module, usedModule)] = true
+			}
+		}
+	}
+
+	dotStrings := []string{"digraph modules {"}
+	dotStrings = append(dotStrings, sortedKeys(modules)...)
+	dotStrings = append(dotStrings, sortedKeys(edges)...)
+	dotStrings = append(dotStrings, "}", "")
+
+	return strings.Join(dotStrings, "\n")
+}
+
+// sortedKeys returns the sorted keys of a map.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// readManifest returns the Manifest at the given path of fsys. A missing manifest is
+// returned as an empty Manifest.
+func readManifest(fsys RemoveFS, p string) (Manifest, error) {
+	var manifest Manifest
+
+	content, err := fsys.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("scad: unable to read manifest %s: %w", p, err)
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import "strings"
+
+// Modifier represents one or more OpenSCAD modifier characters, which prefix a module
+// instantiation to change how it is rendered. Its presence in a struct sets the Modifier
+// of the encoded Function. Modifiers may be stacked, such as "!#".
+type Modifier string
+
+const (
+	// Disable ignores the subtree.
+	Disable Modifier = "*"
+
+	// Root ignores everything but the subtree.
+	Root Modifier = "!"
+
+	// Highlight renders the subtree normally, and also highlights it in transparent pink.
+	Highlight Modifier = "#"
+
+	// Background renders the subtree in transparent gray, excluded from the final render.
+	Background Modifier = "%"
+)
+
+// GetModifier returns the Modifier. It satisfies the ModifierGetter interface.
+func (modifier Modifier) GetModifier() Modifier {
+	return modifier
+}
+
+// valid returns a boolean indicating if the Modifier contains only modifier characters.
+func (modifier Modifier) valid() bool {
+	return strings.Trim(string(modifier), "*!#%") == ""
+}
+
+// Wrap returns a Modified that applies the Modifier to the given child. It enables
+// using a Modifier with Apply.
+func (modifier Modifier) Wrap(child interface{}) Wrapper {
+	return Modified{Modifier: modifier, Child: child}
+}
+
+// Modified applies a Modifier to a single child, which is encoded in its place.
+type Modified struct {
+	Modifier Modifier
+
+	Child interface{}
+}
+
+// Wrap returns a copy of the Modified with the given child.
+func (modified Modified) Wrap(child interface{}) Wrapper {
+	modified.Child = child
+
+	return modified
+}
+
+// EncodeSCAD returns the child, to be encoded with the Modified's Modifier.
+func (modified Modified) EncodeSCAD() (interface{}, error) {
+	return modified.Child, nil
+}
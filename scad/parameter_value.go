@@ -0,0 +1,64 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// parameterValue returns the string form of a value to be used as a parameter, and a
+// boolean indicating if the value is set. Values with a ParameterEncoderFunc registered for
+// their type are encoded by it, ParameterValueGetter values are asked for their value,
+// values of Go's basic kinds are always set, and nil pointers are never set. An error is
+// returned for any other type.
+func parameterValue(v reflect.Value, enc Encoder) (string, bool, error) {
+	if f, ok := enc.parameterEncoder(v.Type()); ok {
+		return f(v.Interface())
+	}
+
+	// nil pointers are unset, even those whose type has the methods of the type they point to
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return "", false, nil
+	}
+
+	if v.Type().Implements(reflect.TypeOf((*ParameterValueGetter)(nil)).Elem()) {
+		value, ok := v.Interface().(ParameterValueGetter).GetParameterValue()
+
+		return value, ok, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "", false, nil
+		}
+
+		return parameterValue(v.Elem(), enc)
+	case reflect.String:
+		return strconv.Quote(v.String()), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), true, nil
+	}
+
+	return "", false, fmt.Errorf("scad: unable to use type (%s) as a parameter value", v.Type())
+}
@@ -16,19 +16,22 @@
 package scad
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 )
 
-// FunctionContent returns the OpenSCAD content for an input interface.
+// FunctionContent returns the OpenSCAD content for an input interface, in the default
+// Format.
 func FunctionContent(i interface{}) (string, error) {
-	fn, err := Encode(i)
-	if err != nil {
-		return "", err
-	}
+	return Writer{}.FunctionContent(i)
+}
 
-	return fn.content()
+// FlatFunctionContent returns the OpenSCAD content for an input interface as a single
+// self-contained file, with all module definitions included, in the default Format.
+func FlatFunctionContent(i interface{}) (string, error) {
+	return Writer{}.FlatFunctionContent(i)
 }
 
 // Write writes a given interface as a Function to the given location.
@@ -41,6 +44,16 @@ func Write(p string, i interface{}) error {
 	return fn.Write(p)
 }
 
+// WriteFS writes a given interface as a Function to the directory p of the given FS.
+func WriteFS(fsys FS, p string, i interface{}) error {
+	fn, err := Encode(i)
+	if err != nil {
+		return err
+	}
+
+	return fn.WriteFS(fsys, p)
+}
+
 // WriteMap writes each interface as a Function to a path of the key.
 func WriteMap(samples map[string]interface{}) error {
 	for name, sample := range samples {
@@ -52,6 +65,42 @@ func WriteMap(samples map[string]interface{}) error {
 	return nil
 }
 
+// WriteMapFS writes each interface as a Function to a directory of the key in the given FS.
+func WriteMapFS(fsys FS, samples map[string]interface{}) error {
+	for name, sample := range samples {
+		if err := WriteFS(fsys, name, sample); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteFlat writes a given interface as a Function to a single self-contained file at the
+// given path.
+func WriteFlat(p string, i interface{}) error {
+	fn, err := Encode(i)
+	if err != nil {
+		return err
+	}
+
+	return fn.WriteFlat(p)
+}
+
+// WriteParameterSets writes the first sample (by name) as a Function to the given location,
+// along with an OpenSCAD Customizer parameter set file containing the customizable module
+// parameters of every sample, keyed by name. The parameter set file has the same name as the
+// module's file, with a ".json" extension, as expected by OpenSCAD.
+func WriteParameterSets(p string, samples map[string]interface{}) error {
+	return Writer{FS: DirFS(p)}.WriteParameterSets("", samples)
+}
+
+// WriteParameterSetsFS writes the first sample (by name) as a Function to the directory p of
+// the given FS, along with its parameter set file, as described by WriteParameterSets.
+func WriteParameterSetsFS(fsys FS, p string, samples map[string]interface{}) error {
+	return Writer{FS: fsys}.WriteParameterSets(p, samples)
+}
+
 // FunctionNameGetter is the interface for types that implement GetFunctionName.
 type FunctionNameGetter interface {
 	// GetFunctionName returns a string representing the function name. The returned
@@ -66,11 +115,31 @@ type ParameterValueGetter interface {
 	GetParameterValue() (string, bool)
 }
 
+// ParameterValueSetter is the interface for types that implement SetParameterValue, the
+// inverse of GetParameterValue, which is used by Decode.
+type ParameterValueSetter interface {
+	// SetParameterValue sets the value from its string form, returning an error if the
+	// value can't be represented by the type.
+	SetParameterValue(value string) error
+}
+
 // ModuleNameGetter is the interface for types that implement GetModuleName.
 type ModuleNameGetter interface {
 	GetModuleName() string
 }
 
+// ModifierGetter is the interface for types that implement GetModifier.
+type ModifierGetter interface {
+	// GetModifier returns the Modifier to apply. The returned Modifier may be empty.
+	GetModifier() Modifier
+}
+
+// VariablesGetter is the interface for types that implement GetVariables.
+type VariablesGetter interface {
+	// GetVariables returns the Variables to assign, in order.
+	GetVariables() []Variable
+}
+
 // SCADEncoder is the interface for types that implement EncodeSCAD.
 type SCADEncoder interface {
 	// EncodeSCAD returns a new interface that should be passed to scad.EncodeSCAD, enabling
@@ -79,7 +148,14 @@ type SCADEncoder interface {
 	EncodeSCAD() (interface{}, error)
 }
 
+// Validator is the interface for types that implement ValidateSCAD.
+type Validator interface {
+	// ValidateSCAD returns an error if the value is invalid and can't be encoded.
+	ValidateSCAD() error
+}
+
 // Encode encodes an interface into a scad.Function. The given interface must be a struct.
+// A Function, or a pointer to one, is returned as-is.
 //
 // The resulting Function will have values applied based on the struct fields implementing
 // one or more of these interfaces:
@@ -102,9 +178,82 @@ type SCADEncoder interface {
 //
 // •The lowercased field name
 //
-// Slice fields set the Children values for the Function.
+// ModifierGetter fields set the Modifier value for the Function. If multiple ModifierGetter
+// fields are present their Modifiers are stacked in field order.
+//
+// VariablesGetter fields, such as Variables, set Assignments values for the Function, or
+// FileAssignments values if the field has the "file" option in its "scad" tag, such as
+// `scad:",file"`.
+//
+// Slice fields set the Children values for the Function. Non-slice fields with the "child"
+// option in their "scad" tag, such as `scad:",child"`, add their value as a single child,
+// unless it is nil. Children are added in field order.
+//
+// The fields of exported, untagged embedded structs are encoded as if they were fields of
+// the embedding struct, unless the embedded struct implements one of these interfaces or has
+// an encoder registered for its type. This allows common fields, such as resolution
+// parameters, to be shared by embedding a struct.
 //
-// An error will be returned if:
+// Fields with a "scad" tag of "-" are ignored, which is useful for SCADEncoder types whose
+// fields are only used by their EncodeSCAD method.
+//
+// Fields with the "parameter" option in their "scad" tag, such as `scad:"width,parameter"`,
+// set ModuleParameters values for the Function, which must also be a module. Their value
+// is found the same way as a Parameter value, but may also be any of Go's basic types, such
+// as float64 or string. If a module parameter field is also a ParameterValueGetter, the
+// Function's Parameter of the same key is set to reference the module parameter by name, so
+// the resulting module definition is independent of the value.
+//
+// Fields with the "customize" option in their "scad" tag are module parameter fields that
+// are also exposed in OpenSCAD's Customizer, setting CustomizerParameters values for the
+// Function. Their tag may also have the options "range=min:max", "step=size",
+// "options=a|b|c" (for a dropdown, where each option may be given as "value:label"), and
+// "group=name" (for a Customizer tab), such as `scad:"width,customize,range=20:100,step=1"`.
+// A "description" tag sets the description shown in the Customizer.
+//
+// Module parameter fields with the "omitempty" option in their "scad" tag are not set when
+// they hold their type's zero value, such as 0 or "".
+//
+// Fields may also have these options in their "scad" tag, which are checked when encoding:
+//
+// • "required", such as `scad:"h,required"`, requires the field to be set. A slice field is
+// set if it isn't empty.
+//
+// • "oneof=group", such as `scad:"d,oneof=r"`, allows at most one field with the same
+// group to be set. Groups are named after the first parameter of the group, such as "r"
+// for the fields of "r" and "d".
+//
+// • "min=value", such as `scad:"r,min=0"`, requires each number of a set value, which may
+// be a vector, to be at least the given value. Values that aren't literal numbers, such as
+// expressions, aren't checked.
+//
+// Types that can't implement these interfaces, such as those of other packages, can be
+// encoded by registering a NodeEncoderFunc for them with RegisterNodeEncoder, or used as
+// parameters by registering a ParameterEncoderFunc with RegisterParameterEncoder. Registered
+// encoders are consulted before any of the interfaces. An Encoder can be used to register
+// encoders for its encodings only. SCADEncoder types with children of their own can return
+// a Block from EncodeSCAD, whose children are encoded with the same encoders.
+//
+// The Function encoded from the value returned by EncodeSCAD keeps its ModuleName, unless
+// the SCADEncoder type has a ModuleName of its own, which replaces it. The replaced module's
+// parameters become parameters of the SCADEncoder's module, as its content still refers to
+// them. This lets wrappers such as Modified apply to modules without hiding them.
+//
+// Module parameters aren't passed to the value returned by EncodeSCAD, which is built from
+// the SCADEncoder's Go values. For modules with different parameter values, such as
+// Die{Width: 60} and Die{Width: 40}, to share one definition, EncodeSCAD must refer to its
+// module parameters by name, such as with value.Var("width"), instead of using their values.
+// Otherwise their definitions differ, and content with both of them can't be written.
+//
+// Validator values have their ValidateSCAD method called before they are encoded, including
+// the values of Children fields. The errors of every invalid value are returned together as
+// ValidationErrors, with the path to each value. The fields of an invalid value are still
+// encoded, so the errors of its children are also found, but its EncodeSCAD method, if any,
+// isn't called.
+//
+// Errors other than ValidationErrors are returned as an *EncodeError, with the path to the
+// value that caused it, such as "Die.Children[0].Dimples.Children[3].Translate". An error
+// will be returned if:
 //
 // • Name is empty after encoding
 //
@@ -112,40 +261,200 @@ type SCADEncoder interface {
 // as long no more than one sets a value)
 //
 // • Multiple Children fields are found
+//
+// • Module parameter fields are found on a type without a ModuleName
+//
+// • A SCADEncoder module and the module it replaces have a module parameter of the same name
+//
+// • A customizable field's Customizer options are invalid
+//
+// • A field's "required", "oneof", or "min" option isn't satisfied
+//
+// • A Validator value's ValidateSCAD method returns an error
+//
+// • A ModifierGetter field returns a Modifier with characters other than "*", "!", "#", or "%"
 func Encode(i interface{}) (Function, error) {
+	return Encoder{}.Encode(i)
+}
+
+// EncodeChildren encodes each of the given children to a Function, for SCADEncoder types
+// whose EncodeSCAD method encodes their own children. The name is the name of the children's
+// field, such as "Children", which starts the paths of any errors. Returning the errors from
+// EncodeSCAD lets Encode complete their paths. Only globally registered encoders are used,
+// so returning a Block from EncodeSCAD is preferred.
+func EncodeChildren(name string, children []interface{}) ([]Function, error) {
+	state := &encodeState{}
+
+	fns, err := state.encodeChildren(children, name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(state.validationErrs) > 0 {
+		return nil, state.validationErrs
+	}
+
+	return fns, nil
+}
+
+// encode encodes an interface into a Function, as described by Encode. The errors returned
+// by Validators are added to the state's validationErrs with their paths, instead of being
+// returned. Other errors are returned as an *EncodeError with the path of the value that
+// caused it.
+func (state *encodeState) encode(i interface{}, path *encodePath) (Function, error) {
+	fn, err := state.encodeValue(i, path)
+	if err != nil {
+		// errors of children already have their own path
+		if _, ok := err.(*EncodeError); !ok {
+			err = &EncodeError{Path: path.String(), Err: err}
+		}
+
+		return Function{}, err
+	}
+
+	return fn, nil
+}
+
+// encodeValue encodes an interface into a Function for encode.
+func (state *encodeState) encodeValue(i interface{}, path *encodePath) (Function, error) {
 	var fn Function
 
 	if i == nil {
 		return Function{}, fmt.Errorf("scad: attempted to encode null value to Function")
 	}
 
+	// registered node encoders are consulted before anything else
+	if nodeEncoder, ok := state.encoder.nodeEncoder(reflect.TypeOf(i)); ok {
+		return state.encodeNode(nodeEncoder, i, path)
+	}
+
+	// a Function is already encoded, and a Block only needs its children encoded
+	switch encodedFn := i.(type) {
+	case Function:
+		return encodedFn, nil
+	case *Function:
+		if encodedFn != nil {
+			return *encodedFn, nil
+		}
+	case Block:
+		return state.encodeBlock(encodedFn, path)
+	case *Block:
+		if encodedFn != nil {
+			return state.encodeBlock(*encodedFn, path)
+		}
+	}
+
 	// be nice and dereference pointers
 	iV := reflect.ValueOf(i)
 	if iV.Kind() == reflect.Ptr {
 		iV = iV.Elem()
 	}
-	iT := iV.Type()
 
 	if iV.Kind() != reflect.Struct {
 		return Function{}, fmt.Errorf("scad: attempted to encode non-struct (%T) to Function", i)
 	}
+	iT := iV.Type()
+
+	// an invalid value's fields are still encoded, so the errors of its children are found,
+	// but it isn't encoded itself
+	isValid := true
+	if validator, ok := i.(Validator); ok {
+		if err := validator.ValidateSCAD(); err != nil {
+			state.validationErrs = append(state.validationErrs, &ValidationError{Path: path.String(), Err: err})
+			isValid = false
+		}
+	}
 
-	for i := 0; i < iT.NumField(); i++ {
-		field := iT.Field(i)
+	checker := fieldChecker{t: iT, encoder: state.encoder}
+	st := state.encoder.structType(iT)
 
-		scadName := field.Tag.Get("scad")
-		if scadName == "" {
-			scadName = strings.ToLower(field.Name)
+	// only one slice field may set Children, though single child fields add to them
+	var hasChildrenSlice bool
+
+	for _, sf := range st.fields {
+		field := sf.field
+		scadName, scadOptions := sf.name, sf.opts
+		isCustomizable, isModuleParameter := sf.isCustomizable, sf.isModuleParameter
+
+		// fields of embedded structs behind nil pointers are absent
+		fieldV, ok := fieldByIndex(iV, field.Index)
+		if !ok {
+			continue
 		}
 
-		fieldV := iV.Field(i)
-		if fieldV.Kind() == reflect.Ptr && !fieldV.IsZero() {
+		// fields with a registered parameter encoder are only parameters, whatever interfaces
+		// their types implement
+		fieldKind := sf.kind
+		if state.encoder.hasParameterEncoder(fieldV.Type()) {
+			fieldKind = parameterEncoderKind
+		} else if fieldV.Kind() == reflect.Ptr && !fieldV.IsZero() {
 			fieldV = fieldV.Elem()
+			fieldKind = sf.elemKind
+		}
+
+		// silently ignore the options of unexported fields, whose values can't be checked
+		if sf.hasChecks && field.IsExported() {
+			if err := checker.check(field, fieldV, scadOptions); err != nil {
+				return Function{}, err
+			}
+		}
+
+		// single child
+		if sf.isChild {
+			// silently ignore unexported child fields
+			if !field.IsExported() {
+				continue
+			}
+
+			if fieldV.Kind() == reflect.Ptr || fieldV.Kind() == reflect.Interface {
+				if fieldV.IsNil() {
+					continue
+				}
+			}
+
+			childI := fieldV.Interface()
+			childPath := &encodePath{parent: path, field: field.Name, index: -1, value: childI}
+
+			child, err := state.encode(childI, childPath)
+			if err != nil {
+				return Function{}, err
+			}
+
+			fn.Children = append(fn.Children, child)
+
+			continue
+		}
+
+		// ModuleParameters
+		if isModuleParameter {
+			// silently ignore unexported module parameter fields
+			if !field.IsExported() {
+				continue
+			}
+
+			gotValue, ok, err := fieldValue(fieldV, scadOptions, state.encoder)
+			if err != nil {
+				return Function{}, err
+			}
+
+			if ok {
+				if replaced := fn.SetModuleParameter(scadName, gotValue); replaced {
+					return Function{}, fmt.Errorf("scad: attempted to encode type (%s) with multiple module parameter fields with the same name: %s", iT, scadName)
+				}
+				state.recordParameter(&fn, scadName)
+			}
+
+			if isCustomizable {
+				if sf.customizerParameterErr != nil {
+					return Function{}, sf.customizerParameterErr
+				}
+
+				fn.CustomizerParameters = append(fn.CustomizerParameters, sf.customizerParameter)
+			}
 		}
-		fieldT := fieldV.Type()
 
 		// ModuleName
-		if fieldT.Implements(reflect.TypeOf((*ModuleNameGetter)(nil)).Elem()) {
+		if fieldKind.isModuleNameGetter {
 			fn.ModuleName = scadName
 
 			if field.IsExported() {
@@ -157,10 +466,46 @@ func Encode(i interface{}) (Function, error) {
 			}
 		}
 
+		// Assignments
+		if fieldKind.isVariablesGetter {
+			// silently ignore unexported VariablesGetter fields, and don't treat them as Children
+			if !field.IsExported() {
+				continue
+			}
+
+			assignments, err := variablesAssignments(fieldV.Interface().(VariablesGetter).GetVariables(), state.encoder)
+			if err != nil {
+				return Function{}, err
+			}
+
+			if scadOptions.has("file") {
+				fn.FileAssignments = append(fn.FileAssignments, assignments...)
+			} else {
+				fn.Assignments = append(fn.Assignments, assignments...)
+			}
+
+			continue
+		}
+
+		// Modifier
+		if fieldKind.isModifierGetter {
+			// silently ignore unexported ModifierGetter fields
+			if !field.IsExported() {
+				continue
+			}
+
+			gotModifier := fieldV.Interface().(ModifierGetter).GetModifier()
+			if !gotModifier.valid() {
+				return Function{}, fmt.Errorf("scad: attempted to encode type (%s) with invalid Modifier: %q", iT, gotModifier)
+			}
+
+			fn.Modifier += gotModifier
+		}
+
 		// Name
-		if fieldT.Implements(reflect.TypeOf((*FunctionNameGetter)(nil)).Elem()) {
+		if fieldKind.isFunctionNameGetter {
 			if fn.Name != "" {
-				return Function{}, fmt.Errorf("scad: attempted to encode type (%T) with multiple FunctionNameGetter fields", i)
+				return Function{}, fmt.Errorf("scad: attempted to encode type (%s) with multiple FunctionNameGetter fields", iT)
 			}
 
 			fnName := scadName
@@ -176,57 +521,130 @@ func Encode(i interface{}) (Function, error) {
 		}
 
 		// Parameters
-		if fieldT.Implements(reflect.TypeOf((*ParameterValueGetter)(nil)).Elem()) {
+		if fieldKind.isParameterValueGetter {
 			// silently ignore unexported ParameterValueGetter fields
 			if !field.IsExported() {
 				continue
 			}
 
-			if gotValue, ok := fieldV.Interface().(ParameterValueGetter).GetParameterValue(); ok {
+			gotValue, ok, err := fieldValue(fieldV, scadOptions, state.encoder)
+			if err != nil {
+				return Function{}, err
+			}
+
+			if ok {
+				// module parameter fields pass the module's parameter along by name
+				if isModuleParameter {
+					gotValue = scadName
+				}
+
 				if replaced := fn.SetParameter(scadName, gotValue); replaced {
-					return Function{}, fmt.Errorf("scad: attempted to encode type (%T) with multiple ParameterValueGetter fields with the same name: %s", i, scadName)
+					return Function{}, fmt.Errorf("scad: attempted to encode type (%s) with multiple ParameterValueGetter fields with the same name: %s", iT, scadName)
 				}
+				state.recordParameter(&fn, scadName)
 			}
 		}
 
 		// Children
-		if fieldV.Kind() == reflect.Slice {
+		if fieldKind.isSlice {
 			// silently ignore unexported slices
 			if !field.IsExported() {
 				continue
 			}
 
-			if fn.Children != nil {
-				return Function{}, fmt.Errorf("scad: attempted to encode type (%T) with multiple slice fields", i)
+			if hasChildrenSlice {
+				return Function{}, fmt.Errorf("scad: attempted to encode type (%s) with multiple slice fields", iT)
 			}
+			hasChildrenSlice = true
 
 			children := make([]Function, fieldV.Len())
 
 			for i := 0; i < fieldV.Len(); i++ {
-				child, err := Encode(fieldV.Index(i).Interface())
+				childI := fieldV.Index(i).Interface()
+				childPath := &encodePath{parent: path, field: field.Name, index: i, value: childI}
+
+				child, err := state.encode(childI, childPath)
 				if err != nil {
 					return Function{}, err
 				}
 
 				children[i] = child
 			}
-			fn.Children = children
+
+			if fn.Children == nil {
+				fn.Children = children
+			} else {
+				fn.Children = append(fn.Children, children...)
+			}
 		}
 	}
 
-	// after all that, if the given interface is a FunctionEncoder, undo everything except module name
-	if iT.Implements(reflect.TypeOf((*SCADEncoder)(nil)).Elem()) {
+	if fn.ModuleParameters != nil && fn.ModuleName == "" {
+		return Function{}, fmt.Errorf("scad: attempted to encode type (%T) with module parameters but no ModuleName", i)
+	}
+
+	if !isValid {
+		return Function{}, nil
+	}
+
+	// after all that, if the given interface is a FunctionEncoder, undo everything except module name,
+	// module parameters, and modifier
+	if st.isSCADEncoder {
 		encodeFn, err := iV.Interface().(SCADEncoder).EncodeSCAD()
 		if err != nil {
+			// EncodeSCAD methods that encode their own values, such as their children, return
+			// the ValidationErrors of that encoding, which are collected here
+			var encodeValidationErrs ValidationErrors
+			if errors.As(err, &encodeValidationErrs) {
+				for _, validationErr := range encodeValidationErrs {
+					state.validationErrs = append(state.validationErrs, &ValidationError{
+						Path: fmt.Sprintf("%s.%s", path, validationErr.Path),
+						Err:  validationErr.Err,
+					})
+				}
+
+				return Function{}, nil
+			}
+
+			// as are their errors, whose paths are relative to this value
+			if encodeErr, ok := err.(*EncodeError); ok {
+				return Function{}, &EncodeError{
+					Path: fmt.Sprintf("%s.%s", path, encodeErr.Path),
+					Err:  encodeErr.Err,
+				}
+			}
+
 			return Function{}, err
 		}
 
-		encoderFn, err := Encode(encodeFn)
+		encoderFn, err := state.encode(encodeFn, path)
 		if err != nil {
 			return Function{}, err
 		}
 
-		encoderFn.ModuleName = fn.ModuleName
+		// the encoded value's module is kept unless the encoding type is a module itself, which
+		// takes on the encoded module's parameters, as its body still refers to them
+		if fn.ModuleName != "" {
+			for _, name := range orderedKeys(encoderFn.ModuleParameters, nil) {
+				if fn.SetModuleParameter(name, encoderFn.ModuleParameters[name]) {
+					return Function{}, fmt.Errorf("scad: attempted to encode module %s with module parameter %s of its encoded module %s", fn.ModuleName, name, encoderFn.ModuleName)
+				}
+			}
+
+			encoderFn.ModuleName = fn.ModuleName
+			encoderFn.ModuleParameters = fn.ModuleParameters
+			encoderFn.CustomizerParameters = append(fn.CustomizerParameters, encoderFn.CustomizerParameters...)
+
+			// the module parameters are declared before the encoded value's parameters
+			encoderFn.ParameterOrder = mergeParameterOrders(fn.ParameterOrder, encoderFn.ParameterOrder)
+		}
+
+		// the encoding type's modifier applies on top of any the encoded value has
+		encoderFn.Modifier = fn.Modifier + encoderFn.Modifier
+
+		// the encoding type's assignments are made before any the encoded value has
+		encoderFn.Assignments = append(fn.Assignments, encoderFn.Assignments...)
+		encoderFn.FileAssignments = append(fn.FileAssignments, encoderFn.FileAssignments...)
 
 		return encoderFn, nil
 	}
@@ -0,0 +1,291 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseNumbers parses a literal number, such as "2", or vector of numbers, such as
+// "[1, 2, 3]", returning the numbers and a boolean indicating if the value was a vector. The
+// final boolean indicates if the value could be parsed.
+func parseNumbers(value string) ([]float64, bool, bool) {
+	value = strings.TrimSpace(value)
+
+	isVector := strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]")
+	if isVector {
+		value = strings.TrimSpace(value[1 : len(value)-1])
+	}
+
+	var numbers []float64
+	for _, numberString := range strings.Split(value, ",") {
+		number, err := strconv.ParseFloat(strings.TrimSpace(numberString), 64)
+		if err != nil {
+			return nil, false, false
+		}
+
+		numbers = append(numbers, number)
+	}
+
+	return numbers, isVector, true
+}
+
+// formatNumbers formats numbers as a vector, rounding away floating point noise from
+// combining them.
+func formatNumbers(numbers []float64) string {
+	numberStrings := make([]string, len(numbers))
+	for i, number := range numbers {
+		number = math.Round(number*1e12) / 1e12
+		numberStrings[i] = strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(numberStrings, ", "))
+}
+
+// vector3 parses a value as a vector of 3 numbers, as used by translate and scale. Missing
+// vector elements are set to fill, and a single number is used for every element if
+// scalarFills is true.
+func vector3(value string, fill float64, scalarFills bool) ([]float64, bool) {
+	numbers, isVector, ok := parseNumbers(value)
+	if !ok || len(numbers) > 3 {
+		return nil, false
+	}
+
+	if !isVector {
+		if !scalarFills {
+			return nil, false
+		}
+
+		fill = numbers[0]
+		numbers = nil
+	}
+
+	for len(numbers) < 3 {
+		numbers = append(numbers, fill)
+	}
+
+	return numbers, true
+}
+
+// soleValue returns the value of the Function's only argument, or only parameter if it has
+// the given name. A boolean is returned indicating if there was such a value.
+func (fn Function) soleValue(name string) (string, bool) {
+	switch {
+	case len(fn.Arguments) == 1 && len(fn.Parameters) == 0:
+		return fn.Arguments[0], true
+	case len(fn.Arguments) == 0 && len(fn.Parameters) == 1:
+		value, ok := fn.Parameters[name]
+
+		return value, ok
+	}
+
+	return "", false
+}
+
+// withSoleValue returns a copy of the Function with its sole value replaced.
+func (fn Function) withSoleValue(name string, value string) Function {
+	if len(fn.Arguments) == 1 {
+		fn.Arguments = []string{value}
+	} else {
+		fn.Parameters = map[string]string{name: value}
+	}
+
+	return fn
+}
+
+// isPlain returns a boolean indicating if the Function has nothing other than its Name,
+// values and Children, so it can be merged or removed without losing anything.
+func (fn Function) isPlain() bool {
+	return fn.ModuleName == "" && len(fn.Assignments) == 0 && len(fn.FileAssignments) == 0 && len(fn.Else) == 0
+}
+
+// transformVectors describes the vector parameter of the transformations that can be merged
+// or dropped by Simplify.
+var transformVectors = map[string]struct {
+	// identity is the element value of the identity vector.
+	identity float64
+
+	// combine combines the element values of nested transformations.
+	combine func(outer, inner float64) float64
+}{
+	"translate": {
+		identity: 0,
+		combine:  func(outer, inner float64) float64 { return outer + inner },
+	},
+	"scale": {
+		identity: 1,
+		combine:  func(outer, inner float64) float64 { return outer * inner },
+	},
+}
+
+// isIdentity returns a boolean indicating if the Function is a transformation that has no
+// effect, such as translate([0, 0, 0]), rotate(0), or scale(1).
+func (fn Function) isIdentity() bool {
+	switch fn.Name {
+	case "translate", "scale":
+		value, ok := fn.soleValue("v")
+		if !ok {
+			return false
+		}
+
+		identity := transformVectors[fn.Name].identity
+		numbers, ok := vector3(value, identity, fn.Name == "scale")
+		if !ok {
+			return false
+		}
+
+		for _, number := range numbers {
+			if number != identity {
+				return false
+			}
+		}
+
+		return true
+	case "rotate":
+		value, ok := fn.soleValue("a")
+		if !ok {
+			return false
+		}
+
+		numbers, _, ok := parseNumbers(value)
+		if !ok {
+			return false
+		}
+
+		for _, number := range numbers {
+			if number != 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// mergeTransform returns the Function merged with its only child, if both are the same
+// mergeable transformation, such as translate. A boolean is returned indicating if they
+// were merged.
+func (fn Function) mergeTransform() (Function, bool) {
+	transform, ok := transformVectors[fn.Name]
+	if !ok || len(fn.Children) != 1 || len(fn.Assignments) > 0 || len(fn.Else) > 0 {
+		return fn, false
+	}
+
+	child := fn.Children[0]
+	if child.Name != fn.Name || !child.isPlain() || child.Modifier != "" {
+		return fn, false
+	}
+
+	value, ok := fn.soleValue("v")
+	if !ok {
+		return fn, false
+	}
+
+	childValue, ok := child.soleValue("v")
+	if !ok {
+		return fn, false
+	}
+
+	numbers, ok := vector3(value, transform.identity, fn.Name == "scale")
+	if !ok {
+		return fn, false
+	}
+
+	childNumbers, ok := vector3(childValue, transform.identity, fn.Name == "scale")
+	if !ok {
+		return fn, false
+	}
+
+	for i := range numbers {
+		numbers[i] = transform.combine(numbers[i], childNumbers[i])
+	}
+
+	merged := fn.withSoleValue("v", formatNumbers(numbers))
+	merged.Children = child.Children
+
+	return merged, true
+}
+
+// isBoolean returns a boolean indicating if the Function is a boolean operation.
+func (fn Function) isBoolean() bool {
+	return fn.Name == "union" || fn.Name == "difference" || fn.Name == "intersection"
+}
+
+// Simplify returns a copy of the Function with redundant operations removed, without
+// changing the resulting geometry:
+//
+// • Nested translate or scale operations with literal values are merged
+//
+// • Identity transformations, such as translate([0, 0, 0]), rotate(0), or scale(1), with a
+// single child are replaced by the child
+//
+// • Unions directly within unions are flattened
+//
+// • Boolean operations with a single child are replaced by the child
+//
+// Modules are never removed or merged, though their content is simplified. The Modifier of a
+// removed operation is applied to the child replacing it.
+func (fn Function) Simplify() Function {
+	fn.Children = simplifyChildren(fn.Children)
+	fn.Else = simplifyChildren(fn.Else)
+
+	if fn.Name == "union" {
+		var children []Function
+
+		for _, child := range fn.Children {
+			if child.Name == "union" && child.isPlain() && child.Modifier == "" {
+				children = append(children, child.Children...)
+			} else {
+				children = append(children, child)
+			}
+		}
+
+		fn.Children = children
+	}
+
+	for merged := true; merged; {
+		fn, merged = fn.mergeTransform()
+	}
+
+	// a group child can't replace its parent, because its own children would be spliced
+	// into the parent's parent
+	if fn.isPlain() && len(fn.Children) == 1 && !fn.Children[0].isGroup() && (fn.isIdentity() || fn.isBoolean()) {
+		child := fn.Children[0]
+		child.Modifier = fn.Modifier + child.Modifier
+
+		return child
+	}
+
+	return fn
+}
+
+// simplifyChildren returns a new slice of the simplified children.
+func simplifyChildren(children []Function) []Function {
+	if children == nil {
+		return nil
+	}
+
+	simplified := make([]Function, len(children))
+	for i, child := range children {
+		simplified[i] = child.Simplify()
+	}
+
+	return simplified
+}
@@ -0,0 +1,250 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunction_Simplify(t *testing.T) {
+	cube := Function{Name: "cube", Arguments: []string{"10"}}
+	sphere := Function{Name: "sphere", Arguments: []string{"5"}}
+
+	tests := []struct {
+		name  string
+		input Function
+		want  Function
+	}{
+		{
+			name: "merge translates",
+			input: Function{
+				Name:       "translate",
+				Parameters: map[string]string{"v": "[1, 2, 3]"},
+				Children: []Function{
+					{
+						Name:      "translate",
+						Arguments: []string{"[0.1, 0.2]"},
+						Children:  []Function{cube},
+					},
+				},
+			},
+			want: Function{
+				Name:       "translate",
+				Parameters: map[string]string{"v": "[1.1, 2.2, 3]"},
+				Children:   []Function{cube},
+			},
+		},
+		{
+			name: "merge scales",
+			input: Function{
+				Name:      "scale",
+				Arguments: []string{"2"},
+				Children: []Function{
+					{
+						Name:       "scale",
+						Parameters: map[string]string{"v": "[1, 2, 3]"},
+						Children: []Function{
+							{
+								Name:       "scale",
+								Parameters: map[string]string{"v": "[0.5, 1, 1]"},
+								Children:   []Function{cube},
+							},
+						},
+					},
+				},
+			},
+			want: Function{
+				Name:      "scale",
+				Arguments: []string{"[1, 4, 6]"},
+				Children:  []Function{cube},
+			},
+		},
+		{
+			name: "merge into identity",
+			input: Function{
+				Name:      "translate",
+				Arguments: []string{"[1, 0, 0]"},
+				Children: []Function{
+					{
+						Name:      "translate",
+						Arguments: []string{"[-1, 0, 0]"},
+						Children:  []Function{cube},
+					},
+				},
+			},
+			want: cube,
+		},
+		{
+			name: "expressions aren't merged",
+			input: Function{
+				Name:      "translate",
+				Arguments: []string{"[x, 0, 0]"},
+				Children: []Function{
+					{
+						Name:      "translate",
+						Arguments: []string{"[1, 0, 0]"},
+						Children:  []Function{cube},
+					},
+				},
+			},
+			want: Function{
+				Name:      "translate",
+				Arguments: []string{"[x, 0, 0]"},
+				Children: []Function{
+					{
+						Name:      "translate",
+						Arguments: []string{"[1, 0, 0]"},
+						Children:  []Function{cube},
+					},
+				},
+			},
+		},
+		{
+			name: "modified child isn't merged",
+			input: Function{
+				Name:      "translate",
+				Arguments: []string{"[1, 0, 0]"},
+				Children: []Function{
+					{
+						Name:      "translate",
+						Arguments: []string{"[1, 0, 0]"},
+						Modifier:  Highlight,
+						Children:  []Function{cube},
+					},
+				},
+			},
+			want: Function{
+				Name:      "translate",
+				Arguments: []string{"[1, 0, 0]"},
+				Children: []Function{
+					{
+						Name:      "translate",
+						Arguments: []string{"[1, 0, 0]"},
+						Modifier:  Highlight,
+						Children:  []Function{cube},
+					},
+				},
+			},
+		},
+		{
+			name: "identity transforms",
+			input: Function{
+				Name:       "rotate",
+				Parameters: map[string]string{"a": "[0, 0, 0]"},
+				Modifier:   Highlight,
+				Children: []Function{
+					{
+						Name:      "scale",
+						Arguments: []string{"1"},
+						Children: []Function{
+							{
+								Name:       "translate",
+								Parameters: map[string]string{"v": "[0, 0, 0]"},
+								Children:   []Function{cube},
+							},
+						},
+					},
+				},
+			},
+			want: Function{Name: "cube", Arguments: []string{"10"}, Modifier: Highlight},
+		},
+		{
+			name: "identity with multiple children",
+			input: Function{
+				Name:      "translate",
+				Arguments: []string{"[0, 0, 0]"},
+				Children:  []Function{cube, sphere},
+			},
+			want: Function{
+				Name:      "translate",
+				Arguments: []string{"[0, 0, 0]"},
+				Children:  []Function{cube, sphere},
+			},
+		},
+		{
+			name: "flatten unions",
+			input: Function{
+				Name: "union",
+				Children: []Function{
+					{Name: "union", Children: []Function{cube, {Name: "union", Children: []Function{sphere, sphere}}}},
+					{Name: "union", Modifier: Highlight, Children: []Function{cube, sphere}},
+				},
+			},
+			want: Function{
+				Name: "union",
+				Children: []Function{
+					cube,
+					sphere,
+					sphere,
+					{Name: "union", Modifier: Highlight, Children: []Function{cube, sphere}},
+				},
+			},
+		},
+		{
+			name: "single child booleans",
+			input: Function{
+				Name: "difference",
+				Children: []Function{
+					{Name: "intersection", Children: []Function{cube}},
+					{Name: "union", Children: []Function{sphere}},
+				},
+			},
+			want: Function{
+				Name:     "difference",
+				Children: []Function{cube, sphere},
+			},
+		},
+		{
+			name: "modules are kept",
+			input: Function{
+				Name: "union",
+				Children: []Function{
+					{
+						ModuleName: "holder",
+						Name:       "union",
+						Children:   []Function{{Name: "translate", Arguments: []string{"[0, 0, 0]"}, Children: []Function{cube}}},
+					},
+				},
+			},
+			want: Function{
+				ModuleName: "holder",
+				Name:       "union",
+				Children:   []Function{cube},
+			},
+		},
+		{
+			name: "group child is kept",
+			input: Function{
+				Name:      "translate",
+				Arguments: []string{"[0, 0, 0]"},
+				Children:  []Function{{Children: []Function{cube, sphere}}},
+			},
+			want: Function{
+				Name:      "translate",
+				Arguments: []string{"[0, 0, 0]"},
+				Children:  []Function{{Children: []Function{cube, sphere}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := test.input.Simplify()
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q Simplify() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"reflect"
+	"strings"
+)
+
+// tagOptions is the comma-separated list of options following the name in a "scad" tag.
+type tagOptions []string
+
+// has returns a boolean indicating if the given option is present.
+func (opts tagOptions) has(option string) bool {
+	for _, opt := range opts {
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// value returns the value of an option given in "option=value" form, and a boolean
+// indicating if the option is present.
+func (opts tagOptions) value(option string) (string, bool) {
+	prefix := option + "="
+
+	for _, opt := range opts {
+		if strings.HasPrefix(opt, prefix) {
+			return strings.TrimPrefix(opt, prefix), true
+		}
+	}
+
+	return "", false
+}
+
+// parseTag returns the name and options of a struct field's "scad" tag. The name will be
+// the lowercased field name if the tag doesn't specify one.
+func parseTag(field reflect.StructField) (string, tagOptions) {
+	tag := field.Tag.Get("scad")
+
+	var opts tagOptions
+	if i := strings.Index(tag, ","); i >= 0 {
+		opts = strings.Split(tag[i+1:], ",")
+		tag = tag[:i]
+	}
+
+	if tag == "" {
+		tag = strings.ToLower(field.Name)
+	}
+
+	return tag, opts
+}
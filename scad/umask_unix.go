@@ -0,0 +1,41 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package scad
+
+import (
+	"io/fs"
+	"sync"
+	"syscall"
+)
+
+var (
+	umaskOnce  sync.Once
+	umaskValue fs.FileMode
+)
+
+// umask returns the process's umask, which is read once, as it can only be read by setting
+// it.
+func umask() fs.FileMode {
+	umaskOnce.Do(func() {
+		mask := syscall.Umask(0)
+		syscall.Umask(mask)
+
+		umaskValue = fs.FileMode(mask)
+	})
+
+	return umaskValue
+}
@@ -0,0 +1,190 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PathStep is a single step of a Path, selecting one of a Function's Children, or one of
+// its Else children.
+type PathStep struct {
+	// Else indicates the step selects from the Function's Else children.
+	Else bool
+
+	// Index is the index of the selected child.
+	Index int
+}
+
+// String returns the PathStep in the form "Children[0]" or "Else[0]".
+func (step PathStep) String() string {
+	field := "Children"
+	if step.Else {
+		field = "Else"
+	}
+
+	return fmt.Sprintf("%s[%d]", field, step.Index)
+}
+
+// Path is the location of a Function within a tree, as the steps taken from the root
+// Function to reach it. The root Function's Path is empty.
+type Path []PathStep
+
+// String returns the Path in the form "Children[0].Else[1]".
+func (p Path) String() string {
+	stepStrings := make([]string, len(p))
+	for i, step := range p {
+		stepStrings[i] = step.String()
+	}
+
+	return strings.Join(stepStrings, ".")
+}
+
+// Get returns the Function found by following the Path from fn, and a boolean indicating
+// if it was found.
+func (p Path) Get(fn Function) (Function, bool) {
+	for _, step := range p {
+		children := fn.Children
+		if step.Else {
+			children = fn.Else
+		}
+
+		if step.Index < 0 || step.Index >= len(children) {
+			return Function{}, false
+		}
+
+		fn = children[step.Index]
+	}
+
+	return fn, true
+}
+
+// child returns a new Path for the child at the given step. A new slice is always created, so
+// Paths passed to callbacks may be kept.
+func (p Path) child(step PathStep) Path {
+	childPath := make(Path, len(p), len(p)+1)
+	copy(childPath, p)
+
+	return append(childPath, step)
+}
+
+// Cursor describes a Function encountered while walking a tree.
+type Cursor struct {
+	// Function is the current Function.
+	Function Function
+
+	// Parent is the current Function's parent, or nil for the root Function. It must not be
+	// modified.
+	Parent *Function
+
+	// Path is the location of the current Function within the tree.
+	Path Path
+}
+
+// Visitor's Visit method is called by Walk for each Function encountered. If the returned
+// Visitor w is not nil, Walk visits each of the Function's children with w.
+type Visitor interface {
+	Visit(c Cursor) (w Visitor)
+}
+
+// Walk traverses a Function tree in depth-first order, starting by calling v.Visit with a
+// Cursor for fn. A Function's Children are visited before its Else children. Modules are
+// walked into like any other Function.
+func Walk(v Visitor, fn Function) {
+	walk(v, Cursor{Function: fn, Path: Path{}})
+}
+
+// walk calls v.Visit for the Cursor, and walks its children with the returned Visitor.
+func walk(v Visitor, c Cursor) {
+	if v = v.Visit(c); v == nil {
+		return
+	}
+
+	parent := c.Function
+
+	for i, child := range parent.Children {
+		walk(v, Cursor{Function: child, Parent: &parent, Path: c.Path.child(PathStep{Index: i})})
+	}
+
+	for i, child := range parent.Else {
+		walk(v, Cursor{Function: child, Parent: &parent, Path: c.Path.child(PathStep{Else: true, Index: i})})
+	}
+}
+
+// inspector is a Visitor for an Inspect function.
+type inspector func(Cursor) bool
+
+// Visit calls the inspector function, returning the inspector if it returned true.
+func (f inspector) Visit(c Cursor) Visitor {
+	if f(c) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses a Function tree in depth-first order, starting by calling f with a
+// Cursor for fn. If f returns true, Inspect calls f for each of the Function's children.
+func Inspect(fn Function, f func(c Cursor) bool) {
+	Walk(inspector(f), fn)
+}
+
+// RewriteFunc is called by Rewrite for each Function in a tree, and returns the Function to
+// replace it with. Returning c.Function keeps it unchanged, and returning the zero Function
+// removes it from its parent.
+type RewriteFunc func(c Cursor) Function
+
+// Rewrite returns a copy of a Function tree with each Function replaced by the result of f.
+// The tree is traversed depth-first, and each Function's children are rewritten before the
+// Function itself, so f receives a Function with its children already rewritten. The Parent
+// and Path of each Cursor refer to the original tree. If the root Function is removed, the
+// zero Function is returned.
+func Rewrite(fn Function, f RewriteFunc) Function {
+	return rewrite(Cursor{Function: fn, Path: Path{}}, f)
+}
+
+// rewrite rewrites the children of the Cursor's Function, then the Function itself.
+func rewrite(c Cursor, f RewriteFunc) Function {
+	parent := c.Function
+
+	rewriteChildren := func(children []Function, isElse bool) []Function {
+		if children == nil {
+			return nil
+		}
+
+		rewritten := make([]Function, 0, len(children))
+		for i, child := range children {
+			childCursor := Cursor{Function: child, Parent: &parent, Path: c.Path.child(PathStep{Else: isElse, Index: i})}
+
+			if rewrittenChild := rewrite(childCursor, f); !isZeroFunction(rewrittenChild) {
+				rewritten = append(rewritten, rewrittenChild)
+			}
+		}
+
+		return rewritten
+	}
+
+	c.Function.Children = rewriteChildren(parent.Children, false)
+	c.Function.Else = rewriteChildren(parent.Else, true)
+
+	return f(c)
+}
+
+// isZeroFunction returns a boolean indicating if the Function is the zero Function.
+func isZeroFunction(fn Function) bool {
+	return reflect.ValueOf(fn).IsZero()
+}
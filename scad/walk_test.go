@@ -0,0 +1,271 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"reflect"
+	"testing"
+)
+
+// walkTestTree is a tree with Children, Else children, and a module.
+var walkTestTree = Function{
+	Name:      "if",
+	Arguments: []string{"x"},
+	Children: []Function{
+		{Name: "cube", Arguments: []string{"1"}},
+		{
+			ModuleName: "holder",
+			Name:       "translate",
+			Arguments:  []string{"[1, 0, 0]"},
+			Children:   []Function{{Name: "sphere", Arguments: []string{"2"}}},
+		},
+	},
+	Else: []Function{
+		{Name: "cylinder", Arguments: []string{"3"}},
+	},
+}
+
+func TestPath_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Path
+		want  string
+	}{
+		{
+			name:  "root",
+			input: Path{},
+			want:  "",
+		},
+		{
+			name:  "nested",
+			input: Path{{Index: 1}, {Else: true, Index: 0}, {Index: 2}},
+			want:  "Children[1].Else[0].Children[2]",
+		},
+	}
+
+	for _, test := range tests {
+		got := test.input.String()
+
+		if got != test.want {
+			t.Errorf("%q String() got\n%q, want\n%q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPath_Get(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  Path
+		want   Function
+		wantOk bool
+	}{
+		{
+			name:   "root",
+			input:  Path{},
+			want:   walkTestTree,
+			wantOk: true,
+		},
+		{
+			name:   "nested",
+			input:  Path{{Index: 1}, {Index: 0}},
+			want:   Function{Name: "sphere", Arguments: []string{"2"}},
+			wantOk: true,
+		},
+		{
+			name:   "else",
+			input:  Path{{Else: true, Index: 0}},
+			want:   Function{Name: "cylinder", Arguments: []string{"3"}},
+			wantOk: true,
+		},
+		{
+			name:  "out of range",
+			input: Path{{Else: true, Index: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		got, gotOk := test.input.Get(walkTestTree)
+
+		if !reflect.DeepEqual(got, test.want) || gotOk != test.wantOk {
+			t.Errorf("%q Get() got\n%#v, %v, want\n%#v, %v", test.name, got, gotOk, test.want, test.wantOk)
+		}
+	}
+}
+
+// pathRecorder is a Visitor that records the Paths and parent Names of visited Functions,
+// and doesn't visit the children of modules.
+type pathRecorder struct {
+	visited []string
+}
+
+func (recorder *pathRecorder) Visit(c Cursor) Visitor {
+	parentName := "<nil>"
+	if c.Parent != nil {
+		parentName = c.Parent.Name
+	}
+
+	recorder.visited = append(recorder.visited, c.Function.Name+" "+c.Path.String()+" "+parentName)
+
+	if c.Function.ModuleName != "" {
+		return nil
+	}
+
+	return recorder
+}
+
+func TestWalk(t *testing.T) {
+	recorder := &pathRecorder{}
+	Walk(recorder, walkTestTree)
+
+	want := []string{
+		"if  <nil>",
+		"cube Children[0] if",
+		"translate Children[1] if",
+		"cylinder Else[0] if",
+	}
+
+	if !reflect.DeepEqual(recorder.visited, want) {
+		t.Errorf("Walk() visited\n%#v, want\n%#v", recorder.visited, want)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	var got []Path
+	Inspect(walkTestTree, func(c Cursor) bool {
+		got = append(got, c.Path)
+
+		return true
+	})
+
+	want := []Path{
+		{},
+		{{Index: 0}},
+		{{Index: 1}},
+		{{Index: 1}, {Index: 0}},
+		{{Else: true, Index: 0}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Inspect() visited\n%#v, want\n%#v", got, want)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		input RewriteFunc
+		want  Function
+	}{
+		{
+			name:  "unchanged",
+			input: func(c Cursor) Function { return c.Function },
+			want:  walkTestTree,
+		},
+		{
+			name: "replace",
+			input: func(c Cursor) Function {
+				if c.Function.Name == "sphere" {
+					return Function{Name: "cube", Arguments: []string{"4"}}
+				}
+
+				return c.Function
+			},
+			want: Function{
+				Name:      "if",
+				Arguments: []string{"x"},
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"1"}},
+					{
+						ModuleName: "holder",
+						Name:       "translate",
+						Arguments:  []string{"[1, 0, 0]"},
+						Children:   []Function{{Name: "cube", Arguments: []string{"4"}}},
+					},
+				},
+				Else: []Function{
+					{Name: "cylinder", Arguments: []string{"3"}},
+				},
+			},
+		},
+		{
+			name: "remove",
+			input: func(c Cursor) Function {
+				if c.Path.String() == "Children[0]" || c.Path.String() == "Else[0]" {
+					return Function{}
+				}
+
+				return c.Function
+			},
+			want: Function{
+				Name:      "if",
+				Arguments: []string{"x"},
+				Children: []Function{
+					{
+						ModuleName: "holder",
+						Name:       "translate",
+						Arguments:  []string{"[1, 0, 0]"},
+						Children:   []Function{{Name: "sphere", Arguments: []string{"2"}}},
+					},
+				},
+				Else: []Function{},
+			},
+		},
+		{
+			name: "children rewritten first",
+			input: func(c Cursor) Function {
+				if c.Function.Name == "cylinder" {
+					return Function{}
+				}
+
+				// the rewritten Function no longer has its removed child
+				if c.Function.Name == "if" && len(c.Function.Else) == 0 {
+					return Function{Name: "union", Children: c.Function.Children}
+				}
+
+				return c.Function
+			},
+			want: Function{
+				Name: "union",
+				Children: []Function{
+					{Name: "cube", Arguments: []string{"1"}},
+					{
+						ModuleName: "holder",
+						Name:       "translate",
+						Arguments:  []string{"[1, 0, 0]"},
+						Children:   []Function{{Name: "sphere", Arguments: []string{"2"}}},
+					},
+				},
+			},
+		},
+		{
+			name:  "remove root",
+			input: func(c Cursor) Function { return Function{} },
+			want:  Function{},
+		},
+	}
+
+	for _, test := range tests {
+		before := walkTestTree.Children[1].Children[0]
+		got := Rewrite(walkTestTree, test.input)
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q Rewrite() got\n%#v, want\n%#v", test.name, got, test.want)
+		}
+
+		if !reflect.DeepEqual(walkTestTree.Children[1].Children[0], before) {
+			t.Errorf("%q Rewrite() modified its input", test.name)
+		}
+	}
+}
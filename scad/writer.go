@@ -0,0 +1,195 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// Writer writes Functions to an FS. Each module's file is written in its own directory, with
+// nested modules written in directories below it, and a manifest of the written files is
+// maintained in the top directory, named ManifestName.
+type Writer struct {
+	// FS is the file system written to. A nil FS writes to the current directory.
+	FS FS
+
+	// RemoveStale causes files listed in an existing manifest, but no longer generated, to be
+	// removed. Directories left empty are also removed if FS is a DirFS. FS must be nil or a
+	// RemoveFS.
+	RemoveStale bool
+
+	// Encoder encodes the values written by Write and WriteMap.
+	Encoder Encoder
+
+	// Format is the style of the written files.
+	Format Format
+}
+
+// fsys returns the FS written to, which is the current directory if FS is nil.
+func (w Writer) fsys() FS {
+	if w.FS == nil {
+		return DirFS(".")
+	}
+
+	return w.FS
+}
+
+// Write writes a given interface as a Function to the directory p.
+func (w Writer) Write(p string, i interface{}) error {
+	fn, err := w.Encoder.Encode(i)
+	if err != nil {
+		return err
+	}
+
+	return w.WriteFunction(p, fn)
+}
+
+// WriteFlat writes a given interface as a Function, with the definitions of all of its
+// modules, to a single self-contained file at the path p.
+func (w Writer) WriteFlat(p string, i interface{}) error {
+	content, err := w.FlatFunctionContent(i)
+	if err != nil {
+		return err
+	}
+
+	return w.fsys().WriteFile(p, []byte(content))
+}
+
+// FunctionContent returns the OpenSCAD content for an input interface, as it would be
+// written by Write.
+func (w Writer) FunctionContent(i interface{}) (string, error) {
+	fn, err := w.Encoder.Encode(i)
+	if err != nil {
+		return "", err
+	}
+
+	return w.Format.Content(fn)
+}
+
+// FlatFunctionContent returns the OpenSCAD content for an input interface as a single
+// self-contained file, as it would be written by WriteFlat.
+func (w Writer) FlatFunctionContent(i interface{}) (string, error) {
+	fn, err := w.Encoder.Encode(i)
+	if err != nil {
+		return "", err
+	}
+
+	return w.Format.FlatContent(fn)
+}
+
+// WriteMap writes each interface as a Function to a directory of the key.
+func (w Writer) WriteMap(samples map[string]interface{}) error {
+	for name, sample := range samples {
+		if err := w.Write(name, sample); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteParameterSets writes the first sample (by name) as a Function to the directory p,
+// along with its parameter set file, as described by the WriteParameterSets function.
+func (w Writer) WriteParameterSets(p string, samples map[string]interface{}) error {
+	fn, sets, err := w.Encoder.EncodeParameterSets(samples)
+	if err != nil {
+		return err
+	}
+
+	if err := w.WriteFunction(p, fn); err != nil {
+		return err
+	}
+
+	setsContent, err := json.MarshalIndent(sets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return w.fsys().WriteFile(path.Join(p, fn.ModuleName+".json"), append(setsContent, '\n'))
+}
+
+// Manifest returns the Manifest of the files that WriteFunction would write for the module,
+// in the Writer's Format.
+func (w Writer) Manifest(fn Function) (Manifest, error) {
+	files, err := fn.moduleFiles("", w.Format)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	return newManifest(files), nil
+}
+
+// WriteFunction writes the module to the directory p, which may be empty to write to the
+// root of the FS. For a Function to be successfully able to WriteFunction, it must be a
+// module (non-empty ModuleName).
+func (w Writer) WriteFunction(p string, fn Function) error {
+	files, err := fn.moduleFiles("", w.Format)
+	if err != nil {
+		return err
+	}
+
+	fsys := w.fsys()
+	manifestPath := path.Join(p, ManifestName)
+
+	var previousManifest Manifest
+	var removeFS RemoveFS
+	if w.RemoveStale {
+		var ok bool
+		if removeFS, ok = fsys.(RemoveFS); !ok {
+			return fmt.Errorf("scad: unable to remove stale files from FS (%T) that isn't a RemoveFS", fsys)
+		}
+
+		if previousManifest, err = readManifest(removeFS, manifestPath); err != nil {
+			return err
+		}
+	}
+
+	generated := map[string]bool{}
+
+	for _, file := range files {
+		if err := fsys.WriteFile(path.Join(p, file.name), file.content); err != nil {
+			return err
+		}
+
+		generated[file.name] = true
+	}
+
+	for _, previousFile := range previousManifest.Files {
+		if generated[previousFile.Path] {
+			continue
+		}
+
+		// don't let a manifest direct removal of files outside of its directory
+		if !fs.ValidPath(previousFile.Path) {
+			return fmt.Errorf("scad: invalid path in manifest %s: %s", manifestPath, previousFile.Path)
+		}
+
+		err := removeFS.Remove(path.Join(p, previousFile.Path))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	manifestContent, err := json.MarshalIndent(newManifest(files), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fsys.WriteFile(manifestPath, append(manifestContent, '\n'))
+}
@@ -0,0 +1,367 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// testModule returns a module Function with the given child module names.
+func testModule(name string, childNames ...string) Function {
+	fn := Function{ModuleName: name, Name: "union"}
+
+	for _, childName := range childNames {
+		fn.Children = append(fn.Children, Function{ModuleName: childName, Name: "cube"})
+	}
+
+	return fn
+}
+
+// mapFSNames returns the sorted file names of a MapFS.
+func mapFSNames(m MapFS) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func TestWriter_WriteFunction_manifest(t *testing.T) {
+	fsys := MapFS{}
+
+	top := testModule("top", "b", "a")
+	top.ModuleParameters = map[string]string{"size": "10"}
+	top.Children[0].Children = []Function{{ModuleName: "a", Name: "cube"}}
+
+	if err := (Writer{FS: fsys}).WriteFunction("out", top); err != nil {
+		t.Fatalf("WriteFunction() returned error: %s", err)
+	}
+
+	var got Manifest
+	if err := json.Unmarshal(fsys["out/"+ManifestName], &got); err != nil {
+		t.Fatalf("Unmarshal() of manifest returned error: %s", err)
+	}
+
+	for i, file := range got.Files {
+		if file.SHA256 != fmt.Sprintf("%x", sha256.Sum256(fsys["out/"+file.Path])) {
+			t.Errorf("manifest file %s has SHA256 %q, which doesn't match its content", file.Path, file.SHA256)
+		}
+
+		got.Files[i].SHA256 = ""
+	}
+
+	want := Manifest{
+		Files: []ManifestFile{
+			{Path: "a/a.scad", Module: "a"},
+			{Path: "b/a/a.scad", Module: "a"},
+			{Path: "b/b.scad", Module: "b", Uses: []string{"b/a/a.scad"}},
+			{Path: "top.scad", Module: "top", ModuleParameters: map[string]string{"size": "10"}, Uses: []string{"a/a.scad", "b/b.scad"}},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("manifest got\n%#v, want\n%#v", got, want)
+	}
+}
+
+func TestWriter_WriteFunction_format(t *testing.T) {
+	fsys := MapFS{}
+
+	w := Writer{FS: fsys, Format: Format{Header: "generated", OmitSelfCall: true}}
+	if err := w.WriteFunction("out", testModule("top", "a")); err != nil {
+		t.Fatalf("WriteFunction() returned error: %s", err)
+	}
+
+	want := map[string]string{
+		"out/top.scad": "// generated\nuse <a/a.scad>\nmodule top() {\n  union() {\n    a();\n  }\n}\n",
+		"out/a/a.scad": "// generated\nmodule a() {\n  cube();\n}\n",
+	}
+
+	for name, wantContent := range want {
+		if got := string(fsys[name]); got != wantContent {
+			t.Errorf("WriteFunction() %s got\n%q, want\n%q", name, got, wantContent)
+		}
+	}
+}
+
+func TestWriter_zero(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() returned error: %s", err)
+	}
+
+	// the zero Writer writes to the current directory
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Chdir() returned error: %s", err)
+	}
+	defer func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatalf("Chdir() returned error: %s", err)
+		}
+	}()
+
+	var w Writer
+	if err := w.Write("out", testModule("top", "a")); err != nil {
+		t.Fatalf("Write() returned error: %s", err)
+	}
+
+	if err := w.WriteFlat("flat.scad", testModule("top", "a")); err != nil {
+		t.Fatalf("WriteFlat() returned error: %s", err)
+	}
+
+	for _, name := range []string{"out/top.scad", "out/a/a.scad", "out/" + ManifestName, "flat.scad"} {
+		if _, err := os.Stat(filepath.FromSlash(name)); err != nil {
+			t.Errorf("Write() didn't write %s: %s", name, err)
+		}
+	}
+}
+
+func TestWriter_Manifest(t *testing.T) {
+	fsys := MapFS{}
+
+	w := Writer{FS: fsys, Format: Format{Header: "generated", Indent: "\t"}}
+	if err := w.WriteFunction("", testModule("top", "a")); err != nil {
+		t.Fatalf("WriteFunction() returned error: %s", err)
+	}
+
+	got, err := w.Manifest(testModule("top", "a"))
+	if err != nil {
+		t.Fatalf("Manifest() returned error: %s", err)
+	}
+
+	var want Manifest
+	if err := json.Unmarshal(fsys[ManifestName], &want); err != nil {
+		t.Fatalf("Unmarshal() of manifest returned error: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Manifest() got\n%#v, want the written manifest\n%#v", got, want)
+	}
+}
+
+// testParameterSetTray is a module with customizable parameters declared out of name order.
+type testParameterSetTray struct {
+	tray ModuleName `scad:"tray"` //nolint:golint,structcheck,unused
+
+	Width float64 `scad:"width,customize"`
+	Depth float64 `scad:"depth,customize"`
+}
+
+func (tray testParameterSetTray) EncodeSCAD() (interface{}, error) {
+	return Function{Name: "square", Parameters: map[string]string{"size": "[width, depth]"}}, nil
+}
+
+func TestWriter_WriteParameterSets(t *testing.T) {
+	fsys := MapFS{}
+
+	w := Writer{FS: fsys, Encoder: Encoder{ParameterOrder: DeclarationOrder}, Format: Format{Precision: 1}}
+	err := w.WriteParameterSets("out", map[string]interface{}{
+		"small": testParameterSetTray{Width: 10.25, Depth: 5},
+		"large": testParameterSetTray{Width: 20, Depth: 10},
+	})
+	if err != nil {
+		t.Fatalf("WriteParameterSets() returned error: %s", err)
+	}
+
+	want := map[string]string{
+		"out/tray.scad": "width = 20;\ndepth = 10;\nmodule tray(width=20, depth=10) {\n  square(size=[width, depth]);\n}\ntray(width=width, depth=depth);\n",
+		"out/tray.json": "{\n  \"parameterSets\": {\n    \"large\": {\n      \"depth\": \"10\",\n      \"width\": \"20\"\n    },\n    \"small\": {\n      \"depth\": \"5\",\n      \"width\": \"10.25\"\n    }\n  },\n  \"fileFormatVersion\": \"1\"\n}\n",
+	}
+
+	for name, wantContent := range want {
+		if got := string(fsys[name]); got != wantContent {
+			t.Errorf("WriteParameterSets() %s got\n%q, want\n%q", name, got, wantContent)
+		}
+	}
+}
+
+func TestManifest_DOT(t *testing.T) {
+	manifest := Manifest{
+		Files: []ManifestFile{
+			{Path: "a/a.scad", Module: "a"},
+			{Path: "b/a/a.scad", Module: "a"},
+			{Path: "b/b.scad", Module: "b", Uses: []string{"b/a/a.scad"}},
+			{Path: "top.scad", Module: "top", Uses: []string{"a/a.scad", "b/b.scad"}},
+		},
+	}
+
+	want := `digraph modules {
+  "a";
+  "b";
+  "top";
+  "b" -> "a";
+  "top" -> "a";
+  "top" -> "b";
+}
+`
+
+	if got := manifest.DOT(); got != want {
+		t.Errorf("DOT() got\n%s, want\n%s", got, want)
+	}
+}
+
+func TestWriter_WriteFunction_removeStale(t *testing.T) {
+	tests := []struct {
+		name        string
+		removeStale bool
+		want        []string
+	}{
+		{
+			name: "keep stale",
+			want: []string{"out/" + ManifestName, "out/a/a.scad", "out/b/b.scad", "out/c/c.scad", "out/top.scad", "unrelated.scad"},
+		},
+		{
+			name:        "remove stale",
+			removeStale: true,
+			want:        []string{"out/" + ManifestName, "out/a/a.scad", "out/c/c.scad", "out/top.scad", "unrelated.scad"},
+		},
+	}
+
+	for _, test := range tests {
+		fsys := MapFS{"unrelated.scad": nil}
+		writer := Writer{FS: fsys, RemoveStale: test.removeStale}
+
+		if err := writer.WriteFunction("out", testModule("top", "a", "b")); err != nil {
+			t.Fatalf("%q WriteFunction() returned error: %s", test.name, err)
+		}
+
+		if err := writer.WriteFunction("out", testModule("top", "a", "c")); err != nil {
+			t.Fatalf("%q WriteFunction() returned error: %s", test.name, err)
+		}
+
+		if got := mapFSNames(fsys); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q WriteFunction() left files %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestWriter_WriteFunction_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		writer Writer
+		fn     Function
+	}{
+		{
+			name:   "non-module",
+			writer: Writer{FS: MapFS{}},
+			fn:     Function{Name: "cube"},
+		},
+		{
+			name:   "remove stale without RemoveFS",
+			writer: Writer{FS: NewZipFS(io.Discard), RemoveStale: true},
+			fn:     testModule("top"),
+		},
+		{
+			name: "manifest path outside of directory",
+			writer: Writer{
+				FS: MapFS{
+					"out/" + ManifestName: []byte(`{"files": [{"path": "../escape.scad"}]}`),
+					"escape.scad":         nil,
+				},
+				RemoveStale: true,
+			},
+			fn: testModule("top"),
+		},
+		{
+			name: "invalid manifest",
+			writer: Writer{
+				FS:          MapFS{"out/" + ManifestName: []byte(`{`)},
+				RemoveStale: true,
+			},
+			fn: testModule("top"),
+		},
+	}
+
+	for _, test := range tests {
+		if err := test.writer.WriteFunction("out", test.fn); err == nil {
+			t.Errorf("%q WriteFunction() returned no error", test.name)
+		}
+	}
+}
+
+func TestDirFS_WriteFile_unchanged(t *testing.T) {
+	dir := t.TempDir()
+	fsys := DirFS(dir)
+	p := filepath.Join(dir, "a.scad")
+
+	if err := fsys.WriteFile("a.scad", []byte("cube();")); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(p, past, past); err != nil {
+		t.Fatalf("Chtimes() returned error: %s", err)
+	}
+
+	if err := fsys.WriteFile("a.scad", []byte("cube();")); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatalf("Stat() returned error: %s", err)
+	}
+
+	if !info.ModTime().Equal(past) {
+		t.Errorf("WriteFile() of unchanged content modified the file")
+	}
+
+	if err := fsys.WriteFile("a.scad", []byte("sphere();")); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() returned error: %s", err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("WriteFile() left %d directory entries, want 1", len(entries))
+	}
+}
+
+func TestDirFS_Remove(t *testing.T) {
+	dir := t.TempDir()
+	fsys := DirFS(dir)
+
+	for _, name := range []string{"a/b/c.scad", "a/d.scad"} {
+		if err := fsys.WriteFile(name, nil); err != nil {
+			t.Fatalf("WriteFile() returned error: %s", err)
+		}
+	}
+
+	if err := fsys.Remove("a/b/c.scad"); err != nil {
+		t.Fatalf("Remove() returned error: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "a", "b")); !os.IsNotExist(err) {
+		t.Errorf("Remove() didn't remove empty parent directory")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "a", "d.scad")); err != nil {
+		t.Errorf("Remove() removed a non-empty parent directory")
+	}
+}
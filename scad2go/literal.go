@@ -0,0 +1,173 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad2go
+
+import (
+	"strconv"
+	"strings"
+
+	"go.incompletion.ist/go-scad/internal/syntax"
+)
+
+// literalKind is the kind of a literal default value.
+type literalKind int
+
+const (
+	literalUnknown literalKind = iota
+	literalBool
+	literalNumber
+	literalString
+	literalVector
+)
+
+// literal is a parsed literal default value.
+type literal struct {
+	kind literalKind
+
+	// integer is true for numbers without a fractional part or exponent.
+	integer bool
+
+	// elements is the elements of a vector.
+	elements []literal
+}
+
+// parseLiteral parses an OpenSCAD expression as a literal. Anything that isn't a bool,
+// number, string, or vector of literals is literalUnknown.
+func parseLiteral(expression string) literal {
+	expression = strings.TrimSpace(expression)
+
+	switch {
+	case expression == "true" || expression == "false":
+		return literal{kind: literalBool}
+	case strings.HasPrefix(expression, `"`):
+		if _, err := strconv.Unquote(expression); err == nil {
+			return literal{kind: literalString}
+		}
+	case strings.HasPrefix(expression, "[") && strings.HasSuffix(expression, "]"):
+		inner := strings.TrimSpace(expression[1 : len(expression)-1])
+		if inner == "" {
+			return literal{kind: literalVector}
+		}
+
+		elementStrings, ok := syntax.SplitElements(inner)
+		if !ok {
+			return literal{}
+		}
+
+		elements := make([]literal, len(elementStrings))
+		for i, elementString := range elementStrings {
+			elements[i] = parseLiteral(elementString)
+			if elements[i].kind == literalUnknown {
+				return literal{}
+			}
+		}
+
+		return literal{kind: literalVector, elements: elements}
+	default:
+		if _, err := strconv.ParseFloat(expression, 64); err == nil {
+			return literal{
+				kind:    literalNumber,
+				integer: !strings.ContainsAny(expression, ".eE"),
+			}
+		}
+	}
+
+	return literal{}
+}
+
+// isNumbers returns a boolean indicating if the literal is a vector of count numbers.
+func (lit literal) isNumbers(count int) bool {
+	if lit.kind != literalVector || len(lit.elements) != count {
+		return false
+	}
+
+	for _, element := range lit.elements {
+		if element.kind != literalNumber {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isIntegerSets returns a boolean indicating if the literal is a non-empty vector of
+// vectors of integers.
+func (lit literal) isIntegerSets() bool {
+	if lit.kind != literalVector || len(lit.elements) == 0 {
+		return false
+	}
+
+	for _, set := range lit.elements {
+		if set.kind != literalVector {
+			return false
+		}
+
+		for _, element := range set.elements {
+			if element.kind != literalNumber || !element.integer {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isPointsXY returns a boolean indicating if the literal is a non-empty vector of XY
+// number pairs.
+func (lit literal) isPointsXY() bool {
+	if lit.kind != literalVector || len(lit.elements) == 0 {
+		return false
+	}
+
+	for _, element := range lit.elements {
+		if !element.isNumbers(2) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valueType returns the name of the type in the value package that best holds a parameter
+// with the given name and default value. Parameters without a default value, or with a
+// default value whose type can't be determined, are value.Float, because most OpenSCAD
+// parameters are numbers. OpenSCAD's numbers are untyped, so numbers are value.Float even
+// if their default is an integer, leaving fractional values settable. Only the counted
+// special variable "$fn" is a value.Int.
+func valueType(name string, defaultValue string) string {
+	// special variables are typed the same way as in the primitive packages
+	if name == "$fn" {
+		return "Int"
+	}
+
+	lit := parseLiteral(defaultValue)
+
+	switch {
+	case lit.kind == literalBool:
+		return "Bool"
+	case lit.kind == literalString:
+		return "String"
+	case lit.isNumbers(2):
+		return "FloatXY"
+	case lit.isNumbers(3):
+		return "FloatXYZ"
+	case lit.isPointsXY():
+		return "FloatsXY"
+	case lit.isIntegerSets():
+		return "IntSets"
+	}
+
+	return "Float"
+}
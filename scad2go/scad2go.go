@@ -0,0 +1,206 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scad2go generates Go types from the module definitions of OpenSCAD files.
+//
+// Each module becomes a struct with a value type field for each of its parameters, in the
+// style of the transformation package. Modules that call children() also get a Children
+// field and a Wrap method, so they can be used with scad.Apply.
+package scad2go
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"go.incompletion.ist/go-scad/parser"
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// GenerateFiles parses each of the given OpenSCAD files and returns the Go source for
+// package pkg, declaring a type for every module they define.
+func GenerateFiles(pkg string, filenames ...string) ([]byte, error) {
+	files := make([]parser.File, len(filenames))
+
+	for i, filename := range filenames {
+		file, err := parser.ParseFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		files[i] = file
+	}
+
+	return Generate(pkg, files...)
+}
+
+// Generate returns the Go source for package pkg, declaring a type for every module defined
+// at the top level of the given parsed files. An error is returned if two modules would result in the same
+// type name.
+func Generate(pkg string, files ...parser.File) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("scad2go: invalid package name: %q", pkg)
+	}
+
+	var modules []parser.Module
+	moduleNamesByType := map[string]string{}
+	needsValue := false
+
+	for _, file := range files {
+		for _, module := range file.Modules {
+			typeName := exportedName(module.Name)
+			if otherName, ok := moduleNamesByType[typeName]; ok {
+				return nil, fmt.Errorf("scad2go: modules %s and %s both result in type %s", otherName, module.Name, typeName)
+			}
+			moduleNamesByType[typeName] = module.Name
+
+			modules = append(modules, module)
+			needsValue = needsValue || len(module.Parameters) > 0
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintln(&buf, "// Code generated by scad2go. DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+
+	fmt.Fprintln(&buf, "import (")
+	fmt.Fprintln(&buf, `"go.incompletion.ist/go-scad/scad"`)
+	if needsValue {
+		fmt.Fprintln(&buf, `"go.incompletion.ist/go-scad/value"`)
+	}
+	fmt.Fprintln(&buf, ")")
+
+	for _, module := range modules {
+		writeModule(&buf, module)
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// writeModule writes the type declaration, and Wrap method if the module accepts children,
+// for a module.
+func writeModule(buf *bytes.Buffer, module parser.Module) {
+	typeName := exportedName(module.Name)
+	fnFieldName := unexportedName(module.Name)
+	hasChildren := callsChildren(module.Body)
+
+	// fields are named after their parameters, but must not collide with other fields
+	usedFieldNames := map[string]bool{fnFieldName: true}
+	if hasChildren {
+		usedFieldNames["Children"] = true
+	}
+
+	fmt.Fprintf(buf, "\n// %s is the %s module.\n", typeName, module.Name)
+	fmt.Fprintf(buf, "type %s struct {\n", typeName)
+	fmt.Fprintf(buf, "%s scad.AutoFunctionName `scad:%q` //nolint:golint,structcheck,unused\n", fnFieldName, module.Name)
+
+	for _, param := range module.Parameters {
+		fmt.Fprintln(buf)
+
+		fieldName := exportedName(param.Name)
+		for usedFieldNames[fieldName] {
+			fieldName += "_"
+		}
+		usedFieldNames[fieldName] = true
+
+		if param.Value == "" {
+			fmt.Fprintf(buf, "// %s has no default value.\n", fieldName)
+		} else {
+			fmt.Fprintf(buf, "// %s defaults to %s.\n", fieldName, param.Value)
+		}
+		fmt.Fprintf(buf, "%s value.%s `scad:%q`\n", fieldName, valueType(param.Name, param.Value), param.Name)
+	}
+
+	if hasChildren {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, "Children []interface{}")
+	}
+
+	fmt.Fprintln(buf, "}")
+
+	if hasChildren {
+		receiver := unexportedName(module.Name)
+		fmt.Fprintf(buf, "\n// Wrap wraps a child with this %s.\n", typeName)
+		fmt.Fprintf(buf, "func (%s %s) Wrap(child interface{}) scad.Wrapper {\n", receiver, typeName)
+		fmt.Fprintf(buf, "%s.Children = append([]interface{}{child}, %s.Children...)\n\n", receiver, receiver)
+		fmt.Fprintf(buf, "return %s\n", receiver)
+		fmt.Fprintln(buf, "}")
+	}
+}
+
+// callsChildren returns a boolean indicating if the Function, or any of its descendents,
+// calls children().
+func callsChildren(fn scad.Function) bool {
+	if fn.Name == "children" {
+		return true
+	}
+
+	for _, child := range append(append([]scad.Function{}, fn.Children...), fn.Else...) {
+		if callsChildren(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nameWords splits an OpenSCAD identifier into words, on underscores and the "$" of special
+// variables.
+func nameWords(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '$'
+	})
+}
+
+// exportedName returns the exported Go identifier for an OpenSCAD identifier. Words are
+// title cased, except for special variables such as "$fn", which are upper cased to
+// match the primitive packages.
+func exportedName(name string) string {
+	words := nameWords(name)
+
+	if strings.HasPrefix(name, "$") {
+		return strings.ToUpper(strings.Join(words, ""))
+	}
+
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	exported := strings.Join(words, "")
+	if exported == "" {
+		exported = "X"
+	}
+
+	return exported
+}
+
+// unexportedName returns the unexported Go identifier for an OpenSCAD identifier.
+func unexportedName(name string) string {
+	runes := []rune(exportedName(name))
+	runes[0] = unicode.ToLower(runes[0])
+
+	unexported := string(runes)
+	if token.IsKeyword(unexported) {
+		unexported += "_"
+	}
+
+	return unexported
+}
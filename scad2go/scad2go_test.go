@@ -0,0 +1,148 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scad2go
+
+import (
+	"testing"
+
+	"go.incompletion.ist/go-scad/parser"
+)
+
+func Test_valueType(t *testing.T) {
+	tests := []struct {
+		name         string
+		defaultValue string
+		want         string
+	}{
+		{name: "size", defaultValue: "", want: "Float"},
+		{name: "size", defaultValue: "10.5", want: "Float"},
+		{name: "h", defaultValue: "10", want: "Float"},
+		{name: "count", defaultValue: "-3", want: "Float"},
+		{name: "size", defaultValue: "-2.5e3", want: "Float"},
+		{name: "$fn", defaultValue: "32", want: "Int"},
+		{name: "center", defaultValue: "true", want: "Bool"},
+		{name: "text", defaultValue: `"a, [b]"`, want: "String"},
+		{name: "scale", defaultValue: "[1, 2]", want: "FloatXY"},
+		{name: "size", defaultValue: "[1, 2.5, 3]", want: "FloatXYZ"},
+		{name: "points", defaultValue: "[[0, 0], [1, 0.5], [0, 1]]", want: "FloatsXY"},
+		{name: "paths", defaultValue: "[[0, 1, 2], [3, 4]]", want: "IntSets"},
+		{name: "size", defaultValue: "wall * 2", want: "Float"},
+		{name: "range", defaultValue: "[0:2]", want: "Float"},
+		{name: "mixed", defaultValue: `[1, "a"]`, want: "Float"},
+	}
+
+	for _, test := range tests {
+		got := valueType(test.name, test.defaultValue)
+
+		if got != test.want {
+			t.Errorf("valueType(%q, %q) got %s, want %s", test.name, test.defaultValue, got, test.want)
+		}
+	}
+}
+
+func Test_exportedName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "box", want: "Box"},
+		{input: "rounded_box", want: "RoundedBox"},
+		{input: "r1", want: "R1"},
+		{input: "$fn", want: "FN"},
+		{input: "_private", want: "Private"},
+	}
+
+	for _, test := range tests {
+		got := exportedName(test.input)
+
+		if got != test.want {
+			t.Errorf("exportedName(%q) got %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	src := `
+module rounded_box(size=[10, 20, 30], center=false, $fn=32) {
+  hull() children();
+}
+module type(count=3) cube(count);
+`
+
+	want := "// Code generated by scad2go. DO NOT EDIT.\n" +
+		"\n" +
+		"package lib\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"go.incompletion.ist/go-scad/scad\"\n" +
+		"\t\"go.incompletion.ist/go-scad/value\"\n" +
+		")\n" +
+		"\n" +
+		"// RoundedBox is the rounded_box module.\n" +
+		"type RoundedBox struct {\n" +
+		"\troundedBox scad.AutoFunctionName `scad:\"rounded_box\"` //nolint:golint,structcheck,unused\n" +
+		"\n" +
+		"\t// Size defaults to [10, 20, 30].\n" +
+		"\tSize value.FloatXYZ `scad:\"size\"`\n" +
+		"\n" +
+		"\t// Center defaults to false.\n" +
+		"\tCenter value.Bool `scad:\"center\"`\n" +
+		"\n" +
+		"\t// FN defaults to 32.\n" +
+		"\tFN value.Int `scad:\"$fn\"`\n" +
+		"\n" +
+		"\tChildren []interface{}\n" +
+		"}\n" +
+		"\n" +
+		"// Wrap wraps a child with this RoundedBox.\n" +
+		"func (roundedBox RoundedBox) Wrap(child interface{}) scad.Wrapper {\n" +
+		"\troundedBox.Children = append([]interface{}{child}, roundedBox.Children...)\n" +
+		"\n" +
+		"\treturn roundedBox\n" +
+		"}\n" +
+		"\n" +
+		"// Type is the type module.\n" +
+		"type Type struct {\n" +
+		"\ttype_ scad.AutoFunctionName `scad:\"type\"` //nolint:golint,structcheck,unused\n" +
+		"\n" +
+		"\t// Count defaults to 3.\n" +
+		"\tCount value.Float `scad:\"count\"`\n" +
+		"}\n"
+
+	file, err := parser.Parse("lib.scad", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %s", err)
+	}
+
+	got, err := Generate("lib", file)
+	if err != nil {
+		t.Fatalf("Generate() returned error: %s", err)
+	}
+
+	if string(got) != want {
+		t.Errorf("Generate() got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGenerate_conflict(t *testing.T) {
+	file, err := parser.Parse("lib.scad", []byte("module my_box() cube(1);\nmodule myBox() cube(2);"))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %s", err)
+	}
+
+	if _, err := Generate("lib", file); err == nil {
+		t.Errorf("Generate() with conflicting type names returned no error")
+	}
+}
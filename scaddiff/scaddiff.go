@@ -0,0 +1,169 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scaddiff reports the structural differences between OpenSCAD files, or directories
+// of generated files, using scad.Diff.
+//
+// Files are compared by their module definitions and top level statements, so differences
+// in formatting and comments are ignored.
+package scaddiff
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.incompletion.ist/go-scad/parser"
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// FileFunction returns a parsed File as a group Function, whose Children are the File's
+// module definitions, as modules, followed by its top level statements. The File's "use"
+// and "include" statements, function definitions, and definitions nested in modules are not
+// included.
+func FileFunction(file parser.File) scad.Function {
+	fn := scad.Function{Assignments: file.Root.Assignments}
+
+	for _, module := range file.Modules {
+		moduleFn := scad.Function{
+			ModuleName:  module.Name,
+			Assignments: module.Body.Assignments,
+			Children:    module.Body.Children,
+		}
+
+		for _, parameter := range module.Parameters {
+			moduleFn.SetModuleParameter(parameter.Name, parameter.Value)
+		}
+
+		fn.Children = append(fn.Children, moduleFn)
+	}
+
+	fn.Children = append(fn.Children, file.Root.Children...)
+
+	return fn
+}
+
+// Files returns the Changes between an old and new parsed File.
+func Files(oldFile, newFile parser.File) []scad.Change {
+	return scad.Diff(FileFunction(oldFile), FileFunction(newFile))
+}
+
+// FileDiff is the difference between the versions of a file in two directories.
+type FileDiff struct {
+	// Name is the slash separated path of the file, relative to the directories.
+	Name string
+
+	// Kind is scad.Added or scad.Removed if the file is only in one of the directories,
+	// otherwise scad.Changed.
+	Kind scad.ChangeKind
+
+	// Changes is the Changes between the versions of a file in both directories.
+	Changes []scad.Change
+}
+
+// Strings returns a description of each difference, prefixed with the file's Name.
+func (diff FileDiff) Strings() []string {
+	if diff.Kind != scad.Changed {
+		return []string{fmt.Sprintf("%s: %s", diff.Name, diff.Kind)}
+	}
+
+	diffStrings := make([]string, len(diff.Changes))
+	for i, change := range diff.Changes {
+		diffStrings[i] = fmt.Sprintf("%s: %s", diff.Name, change)
+	}
+
+	return diffStrings
+}
+
+// scadFiles returns the slash separated paths of the ".scad" files within dir.
+func scadFiles(dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+
+	err := filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(p, ".scad") {
+			return nil
+		}
+
+		name, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(name)] = true
+
+		return nil
+	})
+
+	return files, err
+}
+
+// Dirs returns the differences between the ".scad" files of an old and new directory, such
+// as two directories written by scad.Write, sorted by file name. Files without differences
+// are not included.
+func Dirs(oldDir, newDir string) ([]FileDiff, error) {
+	oldFiles, err := scadFiles(oldDir)
+	if err != nil {
+		return nil, err
+	}
+
+	newFiles, err := scadFiles(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(oldFiles)+len(newFiles))
+	for name := range oldFiles {
+		names = append(names, name)
+	}
+	for name := range newFiles {
+		if !oldFiles[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []FileDiff
+
+	for _, name := range names {
+		switch {
+		case !newFiles[name]:
+			diffs = append(diffs, FileDiff{Name: name, Kind: scad.Removed})
+			continue
+		case !oldFiles[name]:
+			diffs = append(diffs, FileDiff{Name: name, Kind: scad.Added})
+			continue
+		}
+
+		oldFile, err := parser.ParseFile(filepath.Join(oldDir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+
+		newFile, err := parser.ParseFile(filepath.Join(newDir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+
+		if changes := Files(oldFile, newFile); len(changes) > 0 {
+			diffs = append(diffs, FileDiff{Name: name, Kind: scad.Changed, Changes: changes})
+		}
+	}
+
+	return diffs, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaddiff
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+func TestDirs(t *testing.T) {
+	lid := scad.Function{ModuleName: "lid", Name: "cube", Arguments: []string{"[10, 10, 1]"}}
+	box := func(height string, children ...scad.Function) scad.Function {
+		return scad.Function{
+			ModuleName: "box",
+			Name:       "translate",
+			Arguments:  []string{"[0, 0, " + height + "]"},
+			Children:   append([]scad.Function{{Name: "cube", Arguments: []string{"10"}}}, children...),
+		}
+	}
+
+	oldDir := t.TempDir()
+	if err := box("5", lid).Write(oldDir); err != nil {
+		t.Fatalf("Write() returned error: %s", err)
+	}
+
+	newDir := t.TempDir()
+	if err := box("6").Write(newDir); err != nil {
+		t.Fatalf("Write() returned error: %s", err)
+	}
+
+	// formatting differences are ignored
+	if err := os.WriteFile(filepath.Join(oldDir, "extra.scad"), []byte("cube([1,2,3]);"), 0666); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(newDir, "extra.scad"), []byte("cube([1, 2, 3]);\n"), 0666); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+
+	got, err := Dirs(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("Dirs() returned error: %s", err)
+	}
+
+	var gotStrings []string
+	for _, diff := range got {
+		gotStrings = append(gotStrings, diff.Strings()...)
+	}
+
+	want := []string{
+		"box.scad: changed Children[0].Children[0] translate.param:v [0, 0, 5] -> [0, 0, 6]",
+		"box.scad: removed Children[0].Children[0].Children[1] lid",
+		"box.scad: diverged Children[0] module box",
+		"lid/lid.scad: removed",
+	}
+
+	if !reflect.DeepEqual(gotStrings, want) {
+		t.Errorf("Dirs() got\n%#v, want\n%#v", gotStrings, want)
+	}
+}
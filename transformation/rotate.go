@@ -22,8 +22,8 @@ import (
 // Rotate is a rotate transform.
 type Rotate struct {
 	// Only one of A, Axyz may be set.
-	A    value.Float    `scad:"a"`
-	Axyz value.FloatXYZ `scad:"a"`
+	A    value.Float    `scad:"a,oneof=a"`
+	Axyz value.FloatXYZ `scad:"a,oneof=a"`
 
 	V value.FloatXYZ `scad:"v"`
 
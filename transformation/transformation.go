@@ -14,3 +14,21 @@
 
 // Package transformation provides OpenSCAD transformation types.
 package transformation
+
+import (
+	"reflect"
+
+	"go.incompletion.ist/go-scad/scad"
+)
+
+// init registers the types of this package to be decoded by scad.Decode.
+func init() {
+	scad.RegisterType("color", reflect.TypeOf(Color{}))
+	scad.RegisterType("hull", reflect.TypeOf(Hull{}))
+	scad.RegisterType("minkowski", reflect.TypeOf(Minkowski{}))
+	scad.RegisterType("offset", reflect.TypeOf(Offset{}))
+	scad.RegisterType("resize", reflect.TypeOf(Resize{}))
+	scad.RegisterType("rotate", reflect.TypeOf(Rotate{}))
+	scad.RegisterType("scale", reflect.TypeOf(Scale{}))
+	scad.RegisterType("translate", reflect.TypeOf(Translate{}))
+}
@@ -21,3 +21,8 @@ type Bool *explicitValue[bool]
 func NewBool(value bool) Bool {
 	return newExplicitValue(value)
 }
+
+// NewBoolExpr returns a new Bool with the given expression as its value.
+func NewBoolExpr(expr Expr) Bool {
+	return newExplicitExpr[bool](expr)
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value_test
+
+import (
+	"fmt"
+
+	"go.incompletion.ist/go-scad/primitive3d"
+	"go.incompletion.ist/go-scad/scad"
+	"go.incompletion.ist/go-scad/transformation"
+	"go.incompletion.ist/go-scad/value"
+)
+
+func ExampleExpr() {
+	width := value.Var("width")
+	wall := value.Var("wall")
+
+	fmt.Println(width.Sub(wall.Mul(value.Num(2))))
+	fmt.Println(width.Sub(wall).Mul(value.Num(2)))
+	fmt.Println(width.Sub(width.Sub(wall)))
+	fmt.Println(value.Num(-2).Pow(value.Num(2)))
+	fmt.Println(value.Vector(value.Sin(value.Var("a")).Mul(width), value.Num(0), value.Len(value.Var("points"))))
+	fmt.Println(value.Ternary(wall.Gt(value.Num(0)).And(width.Ne(value.Undef)), wall, value.Num(1)))
+	// Output: width - wall * 2
+	// (width - wall) * 2
+	// width - (width - wall)
+	// (-2) ^ 2
+	// [sin(a) * width, 0, len(points)]
+	// wall > 0 && width != undef ? wall : 1
+}
+
+type Tray struct {
+	Name scad.ModuleName `scad:"tray"`
+
+	Width float64 `scad:"width,parameter"`
+	Wall  float64 `scad:"wall,parameter"`
+}
+
+func (tray Tray) EncodeSCAD() (interface{}, error) {
+	width := value.Var("width")
+	wall := value.Var("wall")
+	inner := width.Sub(wall.Mul(value.Num(2)))
+
+	return scad.Apply(
+		primitive3d.Cube{
+			SizeXYZ: value.NewFloatXYZExpr(value.Vector(inner, inner, wall)),
+		},
+		transformation.Translate{
+			V: value.NewFloatXYZExpr(value.Vector(wall, wall, value.Num(0))),
+		},
+	), nil
+}
+
+func ExampleNewFloatXYZExpr() {
+	content, _ := scad.FunctionContent(Tray{Width: 40, Wall: 2})
+	fmt.Println(content)
+	// Output: module tray(wall=2, width=40) {
+	//   translate(v=[wall, wall, 0]) {
+	//     cube(size=[width - wall * 2, width - wall * 2, wall]);
+	//   }
+	// }
+	// tray();
+}
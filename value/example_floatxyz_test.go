@@ -33,3 +33,23 @@ func ExampleFloatXYZ() {
 	// Output: value: [0, 0, 0], ok: false
 	// value: [0, 0, 0], ok: true
 }
+
+func ExampleFloatXYZ_SetParameterValue() {
+	var xyz value.FloatXYZ
+
+	// literal vectors set the values
+	_ = xyz.SetParameterValue("[1,2,3]")
+	fmt.Println(xyz.ValueZ())
+
+	// anything else is kept as an expression
+	_ = xyz.SetParameterValue("[wall, 0, h / 2]")
+	stringValue, _ := xyz.GetParameterValue()
+	fmt.Println(stringValue)
+
+	// vectors of the wrong length aren't a FloatXYZ
+	err := xyz.SetParameterValue("[1, 2]")
+	fmt.Println(err)
+	// Output: 3
+	// [wall, 0, h / 2]
+	// value: unable to set FloatXYZ from vector: [1, 2]
+}
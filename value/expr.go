@@ -0,0 +1,282 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// precedence levels of OpenSCAD operators, from loosest to tightest binding.
+const (
+	precedenceTernary = iota + 1
+	precedenceOr
+	precedenceAnd
+	precedenceEquality
+	precedenceComparison
+	precedenceAdditive
+	precedenceMultiplicative
+	precedenceUnary
+	precedenceExponent
+	precedencePrimary
+)
+
+// Expr is an OpenSCAD expression, such as a variable reference, arithmetic, or a function
+// call. Exprs are built with the functions and methods of this package, which add
+// parentheses only where needed. The zero value is an unset Expr.
+type Expr struct {
+	expr       string
+	precedence int
+}
+
+// String returns the Expr's OpenSCAD source.
+func (e Expr) String() string {
+	return e.expr
+}
+
+// IsSet returns a boolean indicating if the Expr has been set.
+func (e Expr) IsSet() bool {
+	return e.expr != ""
+}
+
+// GetParameterValue returns the Expr's OpenSCAD source, and a boolean indicating if it
+// has been set.
+func (e Expr) GetParameterValue() (string, bool) {
+	return e.expr, e.IsSet()
+}
+
+// SetParameterValue sets the Expr to OpenSCAD source, such as a parameter value read from
+// a file. It never returns an error, as any source is an expression.
+func (e *Expr) SetParameterValue(source string) error {
+	*e = Raw(source)
+
+	return nil
+}
+
+// operand returns the Expr's source for use as an operand that requires at least the given
+// precedence, adding parentheses if the Expr binds more loosely.
+func (e Expr) operand(minPrecedence int) string {
+	if e.precedence < minPrecedence {
+		return fmt.Sprintf("(%s)", e.expr)
+	}
+
+	return e.expr
+}
+
+// primary returns a new Expr that needs no parentheses.
+func primary(expr string) Expr {
+	return Expr{expr: expr, precedence: precedencePrimary}
+}
+
+// binary returns a new Expr for a left-associative binary operator.
+func binary(left Expr, operator string, right Expr, precedence int) Expr {
+	return Expr{
+		expr:       fmt.Sprintf("%s %s %s", left.operand(precedence), operator, right.operand(precedence+1)),
+		precedence: precedence,
+	}
+}
+
+// joinExprs returns the source of the given Exprs, comma separated.
+func joinExprs(exprs []Expr) string {
+	exprStrings := make([]string, len(exprs))
+	for i, e := range exprs {
+		exprStrings[i] = e.expr
+	}
+
+	return strings.Join(exprStrings, ", ")
+}
+
+// Var returns an Expr referencing the named variable, such as "wall" or "$t".
+func Var(name string) Expr {
+	return primary(name)
+}
+
+// Num returns an Expr for a number.
+func Num(value float64) Expr {
+	if value < 0 {
+		return Expr{expr: strconv.FormatFloat(value, 'f', -1, 64), precedence: precedenceUnary}
+	}
+
+	return primary(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// Str returns an Expr for a string.
+func Str(value string) Expr {
+	return primary(fmt.Sprintf("%q", value))
+}
+
+// Boolean returns an Expr for a boolean.
+func Boolean(value bool) Expr {
+	return primary(strconv.FormatBool(value))
+}
+
+// Undef is the Expr for OpenSCAD's undefined value.
+var Undef = primary("undef")
+
+// Vector returns an Expr for a vector of the given elements, such as "[x, y, 0]".
+func Vector(elements ...Expr) Expr {
+	return primary(fmt.Sprintf("[%s]", joinExprs(elements)))
+}
+
+// Range returns an Expr for a range from start to end, inclusive, such as "[0:2:10]".
+func Range(start, step, end Expr) Expr {
+	return primary(fmt.Sprintf("[%s:%s:%s]", start.expr, step.expr, end.expr))
+}
+
+// Call returns an Expr calling the named function with the given arguments.
+func Call(name string, args ...Expr) Expr {
+	return primary(fmt.Sprintf("%s(%s)", name, joinExprs(args)))
+}
+
+// Ternary returns an Expr for "condition ? ifTrue : ifFalse".
+func Ternary(condition, ifTrue, ifFalse Expr) Expr {
+	return Expr{
+		expr: fmt.Sprintf("%s ? %s : %s",
+			condition.operand(precedenceTernary+1),
+			ifTrue.operand(precedenceTernary),
+			ifFalse.operand(precedenceTernary),
+		),
+		precedence: precedenceTernary,
+	}
+}
+
+// Add returns an Expr for "e + other".
+func (e Expr) Add(other Expr) Expr {
+	return binary(e, "+", other, precedenceAdditive)
+}
+
+// Sub returns an Expr for "e - other".
+func (e Expr) Sub(other Expr) Expr {
+	return binary(e, "-", other, precedenceAdditive)
+}
+
+// Mul returns an Expr for "e * other".
+func (e Expr) Mul(other Expr) Expr {
+	return binary(e, "*", other, precedenceMultiplicative)
+}
+
+// Div returns an Expr for "e / other".
+func (e Expr) Div(other Expr) Expr {
+	return binary(e, "/", other, precedenceMultiplicative)
+}
+
+// Mod returns an Expr for "e % other".
+func (e Expr) Mod(other Expr) Expr {
+	return binary(e, "%", other, precedenceMultiplicative)
+}
+
+// Pow returns an Expr for "e ^ other". Exponentiation is right-associative.
+func (e Expr) Pow(other Expr) Expr {
+	return Expr{
+		expr:       fmt.Sprintf("%s ^ %s", e.operand(precedenceExponent+1), other.operand(precedenceExponent)),
+		precedence: precedenceExponent,
+	}
+}
+
+// Neg returns an Expr for "-e".
+func (e Expr) Neg() Expr {
+	return Expr{expr: fmt.Sprintf("-%s", e.operand(precedenceUnary)), precedence: precedenceUnary}
+}
+
+// Not returns an Expr for "!e".
+func (e Expr) Not() Expr {
+	return Expr{expr: fmt.Sprintf("!%s", e.operand(precedenceUnary)), precedence: precedenceUnary}
+}
+
+// Eq returns an Expr for "e == other".
+func (e Expr) Eq(other Expr) Expr {
+	return binary(e, "==", other, precedenceEquality)
+}
+
+// Ne returns an Expr for "e != other".
+func (e Expr) Ne(other Expr) Expr {
+	return binary(e, "!=", other, precedenceEquality)
+}
+
+// Lt returns an Expr for "e < other".
+func (e Expr) Lt(other Expr) Expr {
+	return binary(e, "<", other, precedenceComparison)
+}
+
+// Le returns an Expr for "e <= other".
+func (e Expr) Le(other Expr) Expr {
+	return binary(e, "<=", other, precedenceComparison)
+}
+
+// Gt returns an Expr for "e > other".
+func (e Expr) Gt(other Expr) Expr {
+	return binary(e, ">", other, precedenceComparison)
+}
+
+// Ge returns an Expr for "e >= other".
+func (e Expr) Ge(other Expr) Expr {
+	return binary(e, ">=", other, precedenceComparison)
+}
+
+// And returns an Expr for "e && other".
+func (e Expr) And(other Expr) Expr {
+	return binary(e, "&&", other, precedenceAnd)
+}
+
+// Or returns an Expr for "e || other".
+func (e Expr) Or(other Expr) Expr {
+	return binary(e, "||", other, precedenceOr)
+}
+
+// Index returns an Expr for "e[index]".
+func (e Expr) Index(index Expr) Expr {
+	return primary(fmt.Sprintf("%s[%s]", e.operand(precedencePrimary), index.expr))
+}
+
+// Sin returns an Expr calling sin() with the given angle in degrees.
+func Sin(degrees Expr) Expr {
+	return Call("sin", degrees)
+}
+
+// Cos returns an Expr calling cos() with the given angle in degrees.
+func Cos(degrees Expr) Expr {
+	return Call("cos", degrees)
+}
+
+// Tan returns an Expr calling tan() with the given angle in degrees.
+func Tan(degrees Expr) Expr {
+	return Call("tan", degrees)
+}
+
+// Sqrt returns an Expr calling sqrt().
+func Sqrt(e Expr) Expr {
+	return Call("sqrt", e)
+}
+
+// Abs returns an Expr calling abs().
+func Abs(e Expr) Expr {
+	return Call("abs", e)
+}
+
+// Len returns an Expr calling len().
+func Len(e Expr) Expr {
+	return Call("len", e)
+}
+
+// Min returns an Expr calling min().
+func Min(exprs ...Expr) Expr {
+	return Call("min", exprs...)
+}
+
+// Max returns an Expr calling max().
+func Max(exprs ...Expr) Expr {
+	return Call("max", exprs...)
+}
@@ -21,3 +21,8 @@ type Float *explicitValue[float64]
 func NewFloat(value float64) Float {
 	return newExplicitValue(value)
 }
+
+// NewFloatExpr returns a new Float with the given expression as its value.
+func NewFloatExpr(expr Expr) Float {
+	return newExplicitExpr[float64](expr)
+}
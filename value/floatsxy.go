@@ -33,12 +33,20 @@ func floatTuplesString(floatsTuples ...[]float64) string {
 // FloatsXY represents a list of XY floats that can be explicitly set.
 type FloatsXY struct {
 	value [][2]float64
+	expr  Expr
 	set   bool
 }
 
 // Set sets the given values.
 func (xy *FloatsXY) Set(value ...[2]float64) {
 	xy.value = value
+	xy.expr = Expr{}
+	xy.set = true
+}
+
+// SetExpr explicitly sets the given expression as the value.
+func (xy *FloatsXY) SetExpr(expr Expr) {
+	xy.expr = expr
 	xy.set = true
 }
 
@@ -55,6 +63,10 @@ func (xy FloatsXY) Value() [][2]float64 {
 // GetParameterValue returns a string value for the FloatsXY, and a boolean
 // indicating if its value was explicity set.
 func (xy FloatsXY) GetParameterValue() (string, bool) {
+	if xy.expr.IsSet() {
+		return xy.expr.String(), xy.set
+	}
+
 	valuesSlice := make([][]float64, len(xy.value))
 	for i, values := range xy.value {
 		valuesSlice[i] = make([]float64, len(values))
@@ -68,6 +80,57 @@ func (xy FloatsXY) GetParameterValue() (string, bool) {
 	return valueString, xy.set
 }
 
+// SetParameterValue sets the value from its OpenSCAD source, such as a parameter value read
+// from a file. Vectors with elements that aren't XY vectors of numbers, and other source
+// that isn't a literal, are set as an expression. An error is returned for any other
+// literal.
+func (xy *FloatsXY) SetParameterValue(source string) error {
+	parsed := parseSource(source)
+	if parsed.kind == sourceExpr {
+		xy.SetExpr(Raw(parsed.source))
+
+		return nil
+	}
+
+	if parsed.kind != sourceVector {
+		return wrongKindError(parsed, "FloatsXY")
+	}
+
+	values := make([][2]float64, len(parsed.elements))
+	isLiteral := true
+
+	for i, element := range parsed.elements {
+		if element.kind == sourceExpr {
+			isLiteral = false
+
+			continue
+		}
+
+		numbers, elementIsLiteral, err := element.numbers(2, "FloatsXY")
+		if err != nil {
+			return wrongKindError(parsed, "FloatsXY")
+		}
+
+		if !elementIsLiteral {
+			isLiteral = false
+
+			continue
+		}
+
+		values[i] = [2]float64{numbers[0], numbers[1]}
+	}
+
+	if !isLiteral {
+		xy.SetExpr(Raw(parsed.source))
+
+		return nil
+	}
+
+	xy.Set(values...)
+
+	return nil
+}
+
 // NewFloatsXY creates a new FloatsXY with its value explicitly set.
 func NewFloatsXY(value ...[2]float64) FloatsXY {
 	var xy FloatsXY
@@ -76,6 +139,14 @@ func NewFloatsXY(value ...[2]float64) FloatsXY {
 	return xy
 }
 
+// NewFloatsXYExpr creates a new FloatsXY with the given expression as its value.
+func NewFloatsXYExpr(expr Expr) FloatsXY {
+	var xy FloatsXY
+	xy.SetExpr(expr)
+
+	return xy
+}
+
 // NewFloatsXYRelative returns a new FloatsXY by applying values relatively to the point
 // defined by start.
 func NewFloatsXYRelative(start [2]float64, values ...[2]float64) FloatsXY {
@@ -36,6 +36,7 @@ func floatTupleString(floats ...float64) string {
 type FloatXY struct {
 	valueX float64
 	valueY float64
+	expr   Expr
 	set    bool
 }
 
@@ -43,6 +44,13 @@ type FloatXY struct {
 func (xy *FloatXY) Set(x, y float64) {
 	xy.valueX = x
 	xy.valueY = y
+	xy.expr = Expr{}
+	xy.set = true
+}
+
+// SetExpr explicitly sets the given expression, such as a Vector, as the value.
+func (xy *FloatXY) SetExpr(expr Expr) {
+	xy.expr = expr
 	xy.set = true
 }
 
@@ -64,11 +72,42 @@ func (xy FloatXY) ValueY() float64 {
 // GetParameterValue returns a string value for the FloatXY, and a boolean
 // indicating if its value was explicity set.
 func (xy FloatXY) GetParameterValue() (string, bool) {
+	if xy.expr.IsSet() {
+		return xy.expr.String(), xy.set
+	}
+
 	value := floatTupleString(xy.valueX, xy.valueY)
 
 	return value, xy.set
 }
 
+// SetParameterValue sets the value from its OpenSCAD source, such as a parameter value read
+// from a file. Vectors with elements that aren't numbers, and other source that isn't a
+// literal, are set as an expression. An error is returned for any other literal.
+func (xy *FloatXY) SetParameterValue(source string) error {
+	parsed := parseSource(source)
+	if parsed.kind == sourceExpr {
+		xy.SetExpr(Raw(parsed.source))
+
+		return nil
+	}
+
+	numbers, isLiteral, err := parsed.numbers(2, "FloatXY")
+	if err != nil {
+		return err
+	}
+
+	if !isLiteral {
+		xy.SetExpr(Raw(parsed.source))
+
+		return nil
+	}
+
+	xy.Set(numbers[0], numbers[1])
+
+	return nil
+}
+
 // NewFloatXY creates a new FloatXY with its value explicitly set.
 func NewFloatXY(x, y float64) FloatXY {
 	var xy FloatXY
@@ -76,3 +115,11 @@ func NewFloatXY(x, y float64) FloatXY {
 
 	return xy
 }
+
+// NewFloatXYExpr creates a new FloatXY with the given expression as its value.
+func NewFloatXYExpr(expr Expr) FloatXY {
+	var xy FloatXY
+	xy.SetExpr(expr)
+
+	return xy
+}
@@ -24,6 +24,7 @@ type floatXY struct {
 type FloatXYZ struct {
 	floatXY
 	valueZ float64
+	expr   Expr
 	set    bool
 }
 
@@ -32,6 +33,13 @@ func (xyz *FloatXYZ) Set(x, y, z float64) {
 	xyz.valueX = x
 	xyz.valueY = y
 	xyz.valueZ = z
+	xyz.expr = Expr{}
+	xyz.set = true
+}
+
+// SetExpr explicitly sets the given expression, such as a Vector, as the value.
+func (xyz *FloatXYZ) SetExpr(expr Expr) {
+	xyz.expr = expr
 	xyz.set = true
 }
 
@@ -48,11 +56,42 @@ func (xyz FloatXYZ) ValueZ() float64 {
 // GetParameterValue returns a string value for the FloatXYZ, and a boolean
 // indicating if its value was explicity set.
 func (xyz FloatXYZ) GetParameterValue() (string, bool) {
+	if xyz.expr.IsSet() {
+		return xyz.expr.String(), xyz.set
+	}
+
 	value := floatTupleString(xyz.valueX, xyz.valueY, xyz.valueZ)
 
 	return value, xyz.set
 }
 
+// SetParameterValue sets the value from its OpenSCAD source, such as a parameter value read
+// from a file. Vectors with elements that aren't numbers, and other source that isn't a
+// literal, are set as an expression. An error is returned for any other literal.
+func (xyz *FloatXYZ) SetParameterValue(source string) error {
+	parsed := parseSource(source)
+	if parsed.kind == sourceExpr {
+		xyz.SetExpr(Raw(parsed.source))
+
+		return nil
+	}
+
+	numbers, isLiteral, err := parsed.numbers(3, "FloatXYZ")
+	if err != nil {
+		return err
+	}
+
+	if !isLiteral {
+		xyz.SetExpr(Raw(parsed.source))
+
+		return nil
+	}
+
+	xyz.Set(numbers[0], numbers[1], numbers[2])
+
+	return nil
+}
+
 // NewFloatXYZ creates a new FloatXYZ with its value explicitly set.
 func NewFloatXYZ(x, y, z float64) FloatXYZ {
 	var xyz FloatXYZ
@@ -60,3 +99,11 @@ func NewFloatXYZ(x, y, z float64) FloatXYZ {
 
 	return xyz
 }
+
+// NewFloatXYZExpr creates a new FloatXYZ with the given expression as its value.
+func NewFloatXYZExpr(expr Expr) FloatXYZ {
+	var xyz FloatXYZ
+	xyz.SetExpr(expr)
+
+	return xyz
+}
@@ -21,3 +21,8 @@ type Int *explicitValue[int]
 func NewInt(value int) Int {
 	return newExplicitValue(value)
 }
+
+// NewIntExpr returns a new Int with the given expression as its value.
+func NewIntExpr(expr Expr) Int {
+	return newExplicitExpr[int](expr)
+}
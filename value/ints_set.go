@@ -16,6 +16,7 @@ package value
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -23,12 +24,20 @@ import (
 // IntSets represents an explicitly settable set of integer sets.
 type IntSets struct {
 	value [][]int
+	expr  Expr
 	set   bool
 }
 
 // Set explicitly sets the given value.
 func (i *IntSets) Set(value [][]int) {
 	i.value = value
+	i.expr = Expr{}
+	i.set = true
+}
+
+// SetExpr explicitly sets the given expression as the value.
+func (i *IntSets) SetExpr(expr Expr) {
+	i.expr = expr
 	i.set = true
 }
 
@@ -45,6 +54,10 @@ func (i IntSets) Value() [][]int {
 // GetParameterValue returns the string representation of IntSets, and a boolean
 // indicating if it was explicitly set.
 func (i IntSets) GetParameterValue() (string, bool) {
+	if i.expr.IsSet() {
+		return i.expr.String(), i.set
+	}
+
 	intsStrings := make([]string, len(i.value))
 
 	for j, intValues := range i.value {
@@ -59,6 +72,63 @@ func (i IntSets) GetParameterValue() (string, bool) {
 	return fmt.Sprintf("[ %s ]", strings.Join(intsStrings, ", ")), i.set
 }
 
+// SetParameterValue sets the value from its OpenSCAD source, such as a parameter value read
+// from a file. Vectors with elements that aren't vectors of integers, and other source that
+// isn't a literal, are set as an expression. An error is returned for any other literal.
+func (i *IntSets) SetParameterValue(source string) error {
+	parsed := parseSource(source)
+	if parsed.kind == sourceExpr {
+		i.SetExpr(Raw(parsed.source))
+
+		return nil
+	}
+
+	if parsed.kind != sourceVector {
+		return wrongKindError(parsed, "IntSets")
+	}
+
+	value := make([][]int, len(parsed.elements))
+	isLiteral := true
+
+	for j, element := range parsed.elements {
+		if element.kind == sourceExpr {
+			isLiteral = false
+
+			continue
+		}
+
+		numbers, elementIsLiteral, err := element.numbers(-1, "IntSets")
+		if err != nil {
+			return wrongKindError(parsed, "IntSets")
+		}
+
+		if !elementIsLiteral {
+			isLiteral = false
+
+			continue
+		}
+
+		value[j] = make([]int, len(numbers))
+		for k, number := range numbers {
+			if number != math.Trunc(number) {
+				return wrongKindError(parsed, "IntSets")
+			}
+
+			value[j][k] = int(number)
+		}
+	}
+
+	if !isLiteral {
+		i.SetExpr(Raw(parsed.source))
+
+		return nil
+	}
+
+	i.Set(value)
+
+	return nil
+}
+
 // NewIntSets returns a new IntSets with the given value explicitly set.
 func NewIntSets(value ...[]int) IntSets {
 	var i IntSets
@@ -66,3 +136,11 @@ func NewIntSets(value ...[]int) IntSets {
 
 	return i
 }
+
+// NewIntSetsExpr returns a new IntSets with the given expression as its value.
+func NewIntSetsExpr(expr Expr) IntSets {
+	var i IntSets
+	i.SetExpr(expr)
+
+	return i
+}
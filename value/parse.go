@@ -0,0 +1,136 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.incompletion.ist/go-scad/internal/syntax"
+)
+
+// sourceKind is the kind of literal that OpenSCAD source is, as needed to set a value from
+// it.
+type sourceKind int
+
+const (
+	// sourceExpr is source that isn't a literal, such as a variable reference.
+	sourceExpr sourceKind = iota
+	sourceBool
+	sourceNumber
+	sourceString
+	sourceVector
+)
+
+// String returns the name of the sourceKind, for errors.
+func (kind sourceKind) String() string {
+	switch kind {
+	case sourceBool:
+		return "bool"
+	case sourceNumber:
+		return "number"
+	case sourceString:
+		return "string"
+	case sourceVector:
+		return "vector"
+	}
+
+	return "expression"
+}
+
+// parsedSource is OpenSCAD source parsed as much as needed to set a value from it.
+type parsedSource struct {
+	kind   sourceKind
+	source string
+
+	// number is the value of a sourceNumber.
+	number float64
+
+	// elements are the parsed elements of a sourceVector.
+	elements []parsedSource
+}
+
+// parseSource parses OpenSCAD source, such as a parameter value read from a file. Vectors
+// have their elements parsed. Anything that isn't a literal is a sourceExpr, including
+// ranges, such as "[0:10]".
+func parseSource(source string) parsedSource {
+	source = strings.TrimSpace(source)
+	parsed := parsedSource{source: source}
+
+	switch {
+	case source == "true" || source == "false":
+		parsed.kind = sourceBool
+	case strings.HasPrefix(source, `"`):
+		if _, err := strconv.Unquote(source); err == nil {
+			parsed.kind = sourceString
+		}
+	case strings.HasPrefix(source, "[") && strings.HasSuffix(source, "]"):
+		elementSources, ok := syntax.SplitElements(source[1 : len(source)-1])
+		if !ok {
+			return parsed
+		}
+
+		parsed.kind = sourceVector
+		parsed.elements = make([]parsedSource, len(elementSources))
+		for i, elementSource := range elementSources {
+			parsed.elements[i] = parseSource(elementSource)
+		}
+	default:
+		if number, err := strconv.ParseFloat(source, 64); err == nil {
+			parsed.kind = sourceNumber
+			parsed.number = number
+		}
+	}
+
+	return parsed
+}
+
+// Raw returns an Expr for OpenSCAD source that wasn't built with this package, such as a
+// parameter value read from a file. It is parenthesized when used as an operand, as its
+// precedence is unknown.
+func Raw(source string) Expr {
+	return Expr{expr: strings.TrimSpace(source)}
+}
+
+// wrongKindError returns the error for source of a kind that can't set a value.
+func wrongKindError(parsed parsedSource, want string) error {
+	return fmt.Errorf("value: unable to set %s from %s: %s", want, parsed.kind, parsed.source)
+}
+
+// numbers returns the numbers of a vector of count numbers, or any count if count is
+// negative. A false boolean is returned if any element is an expression, for vectors that
+// must be set as an Expr, and an error is returned for vectors of the wrong shape.
+func (parsed parsedSource) numbers(count int, want string) ([]float64, bool, error) {
+	if parsed.kind != sourceVector || (count >= 0 && len(parsed.elements) != count) {
+		return nil, false, wrongKindError(parsed, want)
+	}
+
+	numbers := make([]float64, len(parsed.elements))
+	isLiteral := true
+
+	for i, element := range parsed.elements {
+		switch element.kind {
+		case sourceNumber:
+			numbers[i] = element.number
+		case sourceExpr:
+			isLiteral = false
+		default:
+			return nil, false, wrongKindError(parsed, want)
+		}
+	}
+
+	return numbers, isLiteral, nil
+}
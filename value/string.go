@@ -21,3 +21,8 @@ type String *explicitValue[string]
 func NewString(value string) String {
 	return newExplicitValue(value)
 }
+
+// NewStringExpr returns a new String with the given expression as its value.
+func NewStringExpr(expr Expr) String {
+	return newExplicitExpr[string](expr)
+}
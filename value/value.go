@@ -15,10 +15,14 @@
 // Package value provides explicitly settable values to be used for OpenSCAD
 // function calls. Only explicitly set values are passed as function arguments.
 // The types in this package adhere to the scad.ParameterValueGetter interface.
+//
+// Values may also be set to an Expr, an OpenSCAD expression such as a variable
+// reference or arithmetic, with the New*Expr functions.
 package value
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 )
@@ -32,6 +36,9 @@ type explicitlyValuable interface {
 type explicitValue[T explicitlyValuable] struct {
 	// value isn't actually unused, but the available golangci-lint version thinks it is
 	value T //nolint:golint,structcheck
+
+	// expr is used in place of value when set.
+	expr Expr
 }
 
 // newExplicitValue returns a pointer to a new explicitValue for the given value.
@@ -43,17 +50,33 @@ func newExplicitValue[T explicitlyValuable](value T) *explicitValue[T] {
 	return &e
 }
 
-// ValueOk returns the stored value and a boolean indicating if the value was set
-// (not a nil pointer). If the pointer is nil, it returns the zero value for
-// its stored type.
+// newExplicitExpr returns a pointer to a new explicitValue with the given expression as its
+// value.
+func newExplicitExpr[T explicitlyValuable](expr Expr) *explicitValue[T] {
+	var e explicitValue[T]
+
+	e.expr = expr
+
+	return &e
+}
+
+// ValueOk returns the stored value and a boolean indicating if a value is stored (not a
+// nil pointer, or an expression). If the pointer is nil, or holds an expression, it returns
+// the zero value for its stored type and false, as an expression's value is only known to
+// OpenSCAD.
 func (e *explicitValue[T]) ValueOk() (T, bool) {
 	var value T
 
-	if e != nil {
-		value = e.value
+	if e == nil || e.IsExpr() {
+		return value, false
 	}
 
-	return value, e != nil
+	return e.value, true
+}
+
+// IsExpr returns a boolean indicating if an expression is stored in place of a value.
+func (e *explicitValue[T]) IsExpr() bool {
+	return e != nil && e.expr.IsSet()
 }
 
 // Value returns the stored value (or the zero value, if a nil pointer).
@@ -63,9 +86,14 @@ func (e *explicitValue[T]) Value() T {
 	return value
 }
 
-// GetParameterValue returns the string representation for the stored value. It
-// always returns true, as this method is not on a pointer.
+// GetParameterValue returns the string representation for the stored value, or
+// its expression if created with one. It always returns true, as this method is
+// not on a pointer.
 func (e explicitValue[T]) GetParameterValue() (string, bool) {
+	if e.expr.IsSet() {
+		return e.expr.String(), true
+	}
+
 	storedValueV := reflect.ValueOf(e.value)
 	storedValueK := storedValueV.Kind()
 
@@ -88,3 +116,49 @@ func (e explicitValue[T]) GetParameterValue() (string, bool) {
 	// this should be unreachable
 	return "", false
 }
+
+// SetParameterValue sets the stored value from its OpenSCAD source, such as a parameter
+// value read from a file. Source that isn't a literal, such as a variable reference, is set
+// as an expression. An error is returned for a literal of the wrong type.
+func (e *explicitValue[T]) SetParameterValue(source string) error {
+	parsed := parseSource(source)
+	if parsed.kind == sourceExpr {
+		*e = explicitValue[T]{expr: Raw(parsed.source)}
+
+		return nil
+	}
+
+	var value T
+
+	switch v := interface{}(&value).(type) {
+	case *float64:
+		if parsed.kind != sourceNumber {
+			return wrongKindError(parsed, "Float")
+		}
+
+		*v = parsed.number
+	case *int:
+		if parsed.kind != sourceNumber || parsed.number != math.Trunc(parsed.number) {
+			return wrongKindError(parsed, "Int")
+		}
+
+		*v = int(parsed.number)
+	case *bool:
+		if parsed.kind != sourceBool {
+			return wrongKindError(parsed, "Bool")
+		}
+
+		*v = parsed.source == "true"
+	case *string:
+		if parsed.kind != sourceString {
+			return wrongKindError(parsed, "String")
+		}
+
+		// parseSource has already checked that it unquotes
+		*v, _ = strconv.Unquote(parsed.source)
+	}
+
+	*e = explicitValue[T]{value: value}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 Micah Kemp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import "testing"
+
+func Test_explicitValue_ValueOk(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      *explicitValue[float64]
+		want       float64
+		wantOk     bool
+		wantIsExpr bool
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:   "value",
+			input:  newExplicitValue(2.5),
+			want:   2.5,
+			wantOk: true,
+		},
+		{
+			name:       "expression",
+			input:      newExplicitExpr[float64](Var("width")),
+			wantIsExpr: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, gotOk := test.input.ValueOk()
+
+		if got != test.want || gotOk != test.wantOk {
+			t.Errorf("%q ValueOk() got %v, %v, want %v, %v", test.name, got, gotOk, test.want, test.wantOk)
+		}
+
+		if gotIsExpr := test.input.IsExpr(); gotIsExpr != test.wantIsExpr {
+			t.Errorf("%q IsExpr() got %v, want %v", test.name, gotIsExpr, test.wantIsExpr)
+		}
+	}
+}